@@ -29,3 +29,9 @@ func Decode(r io.Reader, v any) error {
 	dec := defaultConfig.NewDecoder(r)
 	return dec.Decode(v)
 }
+
+type Decoder = sonic.Decoder
+
+func NewDecoder(r io.Reader) Decoder {
+	return defaultConfig.NewDecoder(r)
+}
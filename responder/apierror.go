@@ -0,0 +1,172 @@
+package responder
+
+import (
+	"maps"
+	"net/http"
+)
+
+// APIError is implemented by errors that declare their own HTTP semantics.
+// HandleErrors finds it anywhere in the wrapped chain via errors.As, so domain
+// packages can return typed errors without registering a central classifier.
+// Empty TypeURI, Title, or PublicDetail values fall back to the responder's
+// status metadata and the error message respectively. A non-empty
+// PublicDetail is always shown, even when a RedactionPolicy hides the error
+// message and the Extensions.
+type APIError interface {
+	error
+	StatusCode() int
+	TypeURI() string
+	Title() string
+	PublicDetail() string
+	Extensions() map[string]any
+}
+
+// StatusError is the stock APIError implementation returned by the helper
+// constructors such as NotFound and Conflict. It wraps the underlying cause so
+// errors.Is and errors.As keep working.
+type StatusError struct {
+	status     int
+	typeURI    string
+	title      string
+	detail     string
+	extensions map[string]any
+	err        error
+}
+
+// NewStatusError wraps err with the supplied HTTP status code.
+func NewStatusError(status int, err error) *StatusError {
+	return &StatusError{status: status, err: err}
+}
+
+// BadRequest wraps err as a 400 Bad Request APIError.
+func BadRequest(err error) *StatusError {
+	return NewStatusError(http.StatusBadRequest, err)
+}
+
+// Unauthorized wraps err as a 401 Unauthorized APIError.
+func Unauthorized(err error) *StatusError {
+	return NewStatusError(http.StatusUnauthorized, err)
+}
+
+// Forbidden wraps err as a 403 Forbidden APIError.
+func Forbidden(err error) *StatusError {
+	return NewStatusError(http.StatusForbidden, err)
+}
+
+// NotFound wraps err as a 404 Not Found APIError.
+func NotFound(err error) *StatusError {
+	return NewStatusError(http.StatusNotFound, err)
+}
+
+// Conflict wraps err as a 409 Conflict APIError.
+func Conflict(err error) *StatusError {
+	return NewStatusError(http.StatusConflict, err)
+}
+
+// Gone wraps err as a 410 Gone APIError.
+func Gone(err error) *StatusError {
+	return NewStatusError(http.StatusGone, err)
+}
+
+// UnprocessableEntity wraps err as a 422 Unprocessable Entity APIError.
+func UnprocessableEntity(err error) *StatusError {
+	return NewStatusError(http.StatusUnprocessableEntity, err)
+}
+
+// TooManyRequests wraps err as a 429 Too Many Requests APIError.
+func TooManyRequests(err error) *StatusError {
+	return NewStatusError(http.StatusTooManyRequests, err)
+}
+
+// ServiceUnavailable wraps err as a 503 Service Unavailable APIError.
+func ServiceUnavailable(err error) *StatusError {
+	return NewStatusError(http.StatusServiceUnavailable, err)
+}
+
+// WithType sets the problem type URI reported for the error.
+func (e *StatusError) WithType(typeURI string) *StatusError {
+	e.typeURI = typeURI
+	return e
+}
+
+// WithTitle sets the problem title reported for the error.
+func (e *StatusError) WithTitle(title string) *StatusError {
+	e.title = title
+	return e
+}
+
+// WithDetail sets the public detail message. Without it the wrapped error's
+// message is used.
+func (e *StatusError) WithDetail(detail string) *StatusError {
+	e.detail = detail
+	return e
+}
+
+// WithExtension adds a problem extension member.
+func (e *StatusError) WithExtension(key string, value any) *StatusError {
+	if e.extensions == nil {
+		e.extensions = make(map[string]any)
+	}
+	e.extensions[key] = value
+	return e
+}
+
+func (e *StatusError) Error() string {
+	switch {
+	case e.err != nil:
+		return e.err.Error()
+	case e.detail != "":
+		return e.detail
+	default:
+		return http.StatusText(e.status)
+	}
+}
+
+// Unwrap exposes the wrapped cause.
+func (e *StatusError) Unwrap() error {
+	return e.err
+}
+
+// StatusCode reports the HTTP status associated with the error.
+func (e *StatusError) StatusCode() int {
+	return e.status
+}
+
+// TypeURI reports the problem type URI, if one was configured.
+func (e *StatusError) TypeURI() string {
+	return e.typeURI
+}
+
+// Title reports the problem title, if one was configured.
+func (e *StatusError) Title() string {
+	return e.title
+}
+
+// PublicDetail reports the detail explicitly marked safe for clients via
+// WithDetail. It is empty when only the wrapped cause is available.
+func (e *StatusError) PublicDetail() string {
+	return e.detail
+}
+
+// Extensions reports the problem extension members.
+func (e *StatusError) Extensions() map[string]any {
+	return maps.Clone(e.extensions)
+}
+
+func applyAPIError(problem *ProblemDetails, apiErr APIError, redacted bool) {
+	if typeURI := apiErr.TypeURI(); typeURI != "" {
+		problem.Type = typeURI
+	}
+	if title := apiErr.Title(); title != "" {
+		problem.Title = title
+	}
+	if detail := apiErr.PublicDetail(); detail != "" {
+		problem.Detail = detail
+	}
+	if redacted {
+		return
+	}
+	for key, value := range apiErr.Extensions() {
+		problem.SetExtension(key, value)
+	}
+}
@@ -0,0 +1,253 @@
+package responder
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// bindSource maps a struct tag to the request values it names.
+type bindSource struct {
+	tag    string
+	lookup func(req *http.Request, name string) []string
+}
+
+var (
+	pathSource = bindSource{tag: "path", lookup: func(req *http.Request, name string) []string {
+		if value := req.PathValue(name); value != "" {
+			return []string{value}
+		}
+		return nil
+	}}
+	querySource = bindSource{tag: "query", lookup: func(req *http.Request, name string) []string {
+		return req.URL.Query()[name]
+	}}
+	headerSource = bindSource{tag: "header", lookup: func(req *http.Request, name string) []string {
+		return req.Header.Values(name)
+	}}
+	cookieSource = bindSource{tag: "cookie", lookup: func(req *http.Request, name string) []string {
+		if cookie, err := req.Cookie(name); err == nil {
+			return []string{cookie.Value}
+		}
+		return nil
+	}}
+
+	allBindSources = []bindSource{pathSource, querySource, headerSource, cookieSource}
+
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// Bind copies request parameters into the struct pointed to by dst, driven by
+// struct tags: `path:"id"`, `query:"limit"`, `header:"X-Tenant"`, and
+// `cookie:"session"`. Strings, booleans, integers, floats, time.Time (RFC 3339
+// unless a `format:"2006-01-02"` tag supplies a layout), time.Duration,
+// encoding.TextUnmarshaler implementations, pointers, and slices of those are
+// supported; repeated query parameters and headers fill slices. Embedded
+// structs and struct pointers are bound recursively; a nil embedded pointer is
+// allocated once one of its parameters is present. Invalid input is answered
+// with a 400 problem listing every offending parameter and Bind returns false.
+func (r *Responder) Bind(w http.ResponseWriter, req *http.Request, dst any) bool {
+	target := reflect.ValueOf(dst)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		r.HandleInternalServerError(w, req, fmt.Errorf("bind: destination must be a non-nil struct pointer, got %T", dst))
+		return false
+	}
+	if err := bindParams(req, dst, allBindSources...); err != nil {
+		r.HandleErrors(w, req, err, "failed to bind request parameters")
+		return false
+	}
+	return true
+}
+
+// bindParams copies tagged request values into the struct pointed to by dst.
+// Conversion failures are reported as a *ValidationError naming each field;
+// any other error signals a programming mistake such as an unsupported field
+// type. Targets that are not struct pointers are left untouched.
+func bindParams(req *http.Request, dst any, sources ...bindSource) error {
+	target := reflect.ValueOf(dst)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	invalid := NewValidationError()
+	if _, err := bindStruct(req, target.Elem(), sources, invalid); err != nil {
+		return err
+	}
+	return invalid.Err()
+}
+
+// bindStruct binds the tagged fields of target and reports whether any
+// request value was found for them.
+func bindStruct(req *http.Request, target reflect.Value, sources []bindSource, invalid *ValidationError) (bool, error) {
+	targetType := target.Type()
+	bound := false
+	for idx := 0; idx < targetType.NumField(); idx++ {
+		field := targetType.Field(idx)
+		var found bool
+		var err error
+		switch {
+		case field.Anonymous && field.Type.Kind() == reflect.Struct:
+			// Exported fields of embedded structs are settable even when the
+			// embedded type itself is unexported.
+			found, err = bindStruct(req, target.Field(idx), sources, invalid)
+		case field.Anonymous && field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct:
+			found, err = bindEmbeddedPointer(req, target.Field(idx), field, sources, invalid)
+		case field.IsExported():
+			found, err = bindField(req, target.Field(idx), field, sources, invalid)
+		}
+		if err != nil {
+			return false, err
+		}
+		bound = bound || found
+	}
+	return bound, nil
+}
+
+// bindEmbeddedPointer binds through an embedded struct pointer, allocating it
+// when a request value is found for one of its fields. Like encoding/json it
+// cannot allocate pointers to unexported types and reports an error instead.
+func bindEmbeddedPointer(req *http.Request, value reflect.Value, field reflect.StructField, sources []bindSource, invalid *ValidationError) (bool, error) {
+	if !value.IsNil() {
+		return bindStruct(req, value.Elem(), sources, invalid)
+	}
+
+	elem := reflect.New(field.Type.Elem())
+	found, err := bindStruct(req, elem.Elem(), sources, invalid)
+	if err != nil || !found {
+		return found, err
+	}
+	if !value.CanSet() {
+		return false, fmt.Errorf("bind: cannot set embedded pointer to unexported struct %s", field.Type.Elem())
+	}
+	value.Set(elem)
+	return true, nil
+}
+
+func bindField(req *http.Request, value reflect.Value, field reflect.StructField, sources []bindSource, invalid *ValidationError) (bool, error) {
+	layout := field.Tag.Get("format")
+	found := false
+	for _, source := range sources {
+		name, ok := field.Tag.Lookup(source.tag)
+		if !ok || name == "" || name == "-" {
+			continue
+		}
+		values := source.lookup(req, name)
+		if len(values) == 0 {
+			continue
+		}
+		found = true
+		if err := setFieldValues(value, values, layout); err != nil {
+			var unsupported *unsupportedBindTypeError
+			if errors.As(err, &unsupported) {
+				return false, fmt.Errorf("bind %s field %s: %w", source.tag, field.Name, err)
+			}
+			invalid.AddField(name, "invalid", err.Error())
+		}
+	}
+	return found, nil
+}
+
+func setFieldValues(field reflect.Value, values []string, layout string) error {
+	switch {
+	case field.Kind() == reflect.Pointer:
+		elem := reflect.New(field.Type().Elem())
+		if err := setFieldValues(elem.Elem(), values, layout); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8:
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for idx, value := range values {
+			if err := setFieldValues(slice.Index(idx), []string{value}, layout); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	default:
+		return setScalar(field, values[0], layout)
+	}
+}
+
+func setScalar(field reflect.Value, raw, layout string) error {
+	switch field.Type() {
+	case timeType:
+		return setTime(field, raw, layout)
+	case durationType:
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid duration", raw)
+		}
+		field.SetInt(int64(parsed))
+		return nil
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		unmarshaler, _ := field.Addr().Interface().(encoding.TextUnmarshaler)
+		if err := unmarshaler.UnmarshalText([]byte(raw)); err != nil {
+			return fmt.Errorf("%q is not a valid %s", raw, field.Type())
+		}
+		return nil
+	}
+	return setBasic(field, raw)
+}
+
+func setTime(field reflect.Value, raw, layout string) error {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	parsed, err := time.Parse(layout, raw)
+	if err != nil {
+		return fmt.Errorf("%q does not match the time format %s", raw, layout)
+	}
+	field.Set(reflect.ValueOf(parsed))
+	return nil
+}
+
+func setBasic(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid %s", raw, field.Type())
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid %s", raw, field.Type())
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid number", raw)
+		}
+		field.SetFloat(parsed)
+	default:
+		return &unsupportedBindTypeError{typ: field.Type()}
+	}
+	return nil
+}
+
+type unsupportedBindTypeError struct {
+	typ reflect.Type
+}
+
+func (e *unsupportedBindTypeError) Error() string {
+	return "unsupported type " + e.typ.String()
+}
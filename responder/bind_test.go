@@ -0,0 +1,66 @@
+package responder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type BindPaging struct {
+	Limit int `query:"limit"`
+}
+
+type bindTenant struct {
+	Tenant string `header:"X-Tenant"`
+}
+
+type bindTrace struct {
+	TraceID string `header:"X-Trace"`
+}
+
+func TestBindParamsEmbeddedStructs(t *testing.T) {
+	type params struct {
+		*BindPaging
+		bindTenant
+		Sort string `query:"sort"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/items?limit=5&sort=name", nil)
+	req.Header.Set("X-Tenant", "acme")
+	var got params
+	if err := bindParams(req, &got, allBindSources...); err != nil {
+		t.Fatalf("bind: %v", err)
+	}
+	if got.BindPaging == nil || got.Limit != 5 || got.Tenant != "acme" || got.Sort != "name" {
+		t.Fatalf("unexpected binding: %+v", got)
+	}
+
+	var untouched params
+	if err := bindParams(httptest.NewRequest(http.MethodGet, "/items", nil), &untouched, allBindSources...); err != nil {
+		t.Fatalf("bind: %v", err)
+	}
+	if untouched.BindPaging != nil {
+		t.Fatal("expected the embedded pointer to stay nil without parameters")
+	}
+}
+
+func TestBindParamsUnexportedEmbeddedPointer(t *testing.T) {
+	type params struct {
+		*bindTrace
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("X-Trace", "t-1")
+
+	preset := params{bindTrace: &bindTrace{}}
+	if err := bindParams(req, &preset, allBindSources...); err != nil || preset.TraceID != "t-1" {
+		t.Fatalf("expected binding through an allocated pointer, got %+v (%v)", preset, err)
+	}
+
+	var unset params
+	err := bindParams(req, &unset, allBindSources...)
+	if err == nil || !strings.Contains(err.Error(), "unexported struct") {
+		t.Fatalf("expected an error for a nil unexported embedded pointer, got %v", err)
+	}
+}
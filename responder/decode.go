@@ -0,0 +1,104 @@
+package responder
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DecodeOption tunes how ReadRequestBody and Handle decode request bodies.
+type DecodeOption func(*decodeConfig)
+
+type decodeConfig struct {
+	maxBytes              int64
+	contentTypes          []string
+	disallowUnknownFields bool
+	singleValue           bool
+	optionalBody          bool
+}
+
+// WithDecodeOptions sets the decode options applied to every request body
+// read by the responder. Options passed to ReadRequestBody are applied after
+// these defaults.
+func WithDecodeOptions(opts ...DecodeOption) ResponderOption {
+	return func(r *Responder) {
+		r.decodeOptions = append(r.decodeOptions, opts...)
+	}
+}
+
+// WithMaxBodyBytes limits the number of bytes read from the request body.
+// Larger bodies are rejected with 413 Request Entity Too Large.
+func WithMaxBodyBytes(limit int64) DecodeOption {
+	return func(cfg *decodeConfig) {
+		cfg.maxBytes = limit
+	}
+}
+
+// WithRequiredContentType rejects requests whose Content-Type does not match
+// one of the supplied media types with 415 Unsupported Media Type. Parameters
+// such as charset are ignored when comparing.
+func WithRequiredContentType(mediaTypes ...string) DecodeOption {
+	return func(cfg *decodeConfig) {
+		cfg.contentTypes = cfg.contentTypes[:0]
+		for _, mediaType := range mediaTypes {
+			if essence := mediaTypeEssence(mediaType); essence != "" {
+				cfg.contentTypes = append(cfg.contentTypes, essence)
+			}
+		}
+	}
+}
+
+// WithDisallowUnknownFields rejects object members that do not map to an
+// exported field of the destination struct.
+func WithDisallowUnknownFields() DecodeOption {
+	return func(cfg *decodeConfig) {
+		cfg.disallowUnknownFields = true
+	}
+}
+
+// WithSingleValue rejects bodies that contain anything but whitespace after
+// the first JSON value.
+func WithSingleValue() DecodeOption {
+	return func(cfg *decodeConfig) {
+		cfg.singleValue = true
+	}
+}
+
+// WithOptionalBody accepts missing or empty bodies and leaves the destination
+// untouched instead of reporting 400 Bad Request. Empty bodies skip the
+// WithRequiredContentType check, since clients rarely label them.
+func WithOptionalBody() DecodeOption {
+	return func(cfg *decodeConfig) {
+		cfg.optionalBody = true
+	}
+}
+
+func (r *Responder) decodeConfig(opts []DecodeOption) decodeConfig {
+	var cfg decodeConfig
+	for _, opt := range r.decodeOptions {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return cfg
+}
+
+func (cfg decodeConfig) checkContentType(req *http.Request) error {
+	if len(cfg.contentTypes) == 0 {
+		return nil
+	}
+
+	provided := mediaTypeEssence(req.Header.Get("Content-Type"))
+	for _, allowed := range cfg.contentTypes {
+		if provided == allowed {
+			return nil
+		}
+	}
+	return NewStatusError(http.StatusUnsupportedMediaType,
+		fmt.Errorf("content type %q is not supported; use %s", provided, strings.Join(cfg.contentTypes, ", ")))
+}
@@ -0,0 +1,295 @@
+package responder
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+
+	"github.com/drblury/apiweaver/jsonutil"
+)
+
+const (
+	xmlContentType        = "application/xml"
+	problemXMLContentType = "application/problem+xml"
+	yamlContentType       = "application/yaml"
+	cborContentType       = "application/cbor"
+	msgpackContentType    = "application/msgpack"
+	textContentType       = "text/plain; charset=utf-8"
+
+	xmlRootElement = "response"
+)
+
+// ErrUnsupportedValue is returned by an EncodeFunc for payloads its media type
+// cannot represent. Respond then moves on to the next acceptable encoder.
+var ErrUnsupportedValue = errors.New("responder: value not supported by encoder")
+
+// EncodeFunc serialises a payload into the representation of a single media
+// type. It returns an error wrapping ErrUnsupportedValue for payloads the
+// media type cannot represent.
+type EncodeFunc func(v any) ([]byte, error)
+
+// Encoder couples a media type with the function that renders payloads in it.
+// ProblemMediaType, when set, is advertised for problem documents instead of
+// MediaType (for example application/problem+json).
+type Encoder struct {
+	MediaType        string
+	ProblemMediaType string
+	Encode           EncodeFunc
+}
+
+// JSONEncoder renders payloads as application/json using jsonutil.
+func JSONEncoder() Encoder {
+	return Encoder{MediaType: jsonContentType, ProblemMediaType: problemContentType, Encode: encodeJSON}
+}
+
+// XMLEncoder renders payloads as application/xml. Structs and xml.Marshaler
+// values use encoding/xml; other payloads, such as maps and slices, follow
+// their JSON representation inside a <response> element, with arrays written
+// as repeated <i> elements. Problem documents use the RFC 9457
+// application/problem+xml representation.
+func XMLEncoder() Encoder {
+	return Encoder{MediaType: xmlContentType, ProblemMediaType: problemXMLContentType, Encode: encodeXML}
+}
+
+// YAMLEncoder renders payloads as application/yaml. Field names follow the
+// payload's JSON representation.
+func YAMLEncoder() Encoder {
+	return Encoder{MediaType: yamlContentType, Encode: encodeYAML}
+}
+
+// CBOREncoder renders payloads as application/cbor. Field names follow the
+// payload's JSON representation.
+func CBOREncoder() Encoder {
+	return Encoder{MediaType: cborContentType, Encode: encodeCBOR}
+}
+
+// MessagePackEncoder renders payloads as application/msgpack. Field names
+// follow the payload's JSON representation.
+func MessagePackEncoder() Encoder {
+	return Encoder{MediaType: msgpackContentType, Encode: encodeMessagePack}
+}
+
+// TextEncoder renders strings, byte slices, fmt.Stringer and
+// encoding.TextMarshaler values as text/plain. Other values are reported as
+// ErrUnsupportedValue.
+func TextEncoder() Encoder {
+	return Encoder{MediaType: textContentType, Encode: encodeText}
+}
+
+// WithEncoders replaces the encoder registry used by Respond and problem
+// responses. The order expresses server preference: the first encoder is used
+// when the client does not send an Accept header.
+func WithEncoders(encoders ...Encoder) ResponderOption {
+	return func(r *Responder) {
+		r.encoders = filterEncoders(encoders)
+	}
+}
+
+// WithEncoder registers an additional encoder, replacing any existing encoder
+// for the same media type.
+func WithEncoder(encoder Encoder) ResponderOption {
+	return func(r *Responder) {
+		if encoder.Encode == nil || encoder.MediaType == "" {
+			return
+		}
+		for idx, existing := range r.encoders {
+			if mediaTypeEssence(existing.MediaType) == mediaTypeEssence(encoder.MediaType) {
+				r.encoders[idx] = encoder
+				return
+			}
+		}
+		r.encoders = append(r.encoders, encoder)
+	}
+}
+
+func defaultEncoders() []Encoder {
+	return []Encoder{
+		JSONEncoder(),
+		XMLEncoder(),
+		YAMLEncoder(),
+		CBOREncoder(),
+		MessagePackEncoder(),
+		TextEncoder(),
+	}
+}
+
+func filterEncoders(encoders []Encoder) []Encoder {
+	filtered := make([]Encoder, 0, len(encoders))
+	for _, encoder := range encoders {
+		if encoder.Encode != nil && encoder.MediaType != "" {
+			filtered = append(filtered, encoder)
+		}
+	}
+	return filtered
+}
+
+func (e Encoder) contentType(problem bool) string {
+	if problem && e.ProblemMediaType != "" {
+		return e.ProblemMediaType
+	}
+	return e.MediaType
+}
+
+func encodeJSON(v any) ([]byte, error) {
+	data, err := jsonutil.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return withTrailingNewline(data), nil
+}
+
+func encodeXML(v any) ([]byte, error) {
+	data, err := marshalXML(v)
+	if err != nil {
+		return nil, err
+	}
+	return withTrailingNewline(append([]byte(xml.Header), data...)), nil
+}
+
+// marshalXML keeps encoding/xml for values that describe their XML form and
+// falls back to the JSON representation for everything encoding/xml rejects.
+func marshalXML(v any) ([]byte, error) {
+	if describesXML(v) {
+		data, err := xml.Marshal(v)
+		var unsupported *xml.UnsupportedTypeError
+		if !errors.As(err, &unsupported) {
+			return data, err
+		}
+	}
+
+	normalized, err := normalizeJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	if err := encodeXMLValue(enc, xmlRootElement, normalized); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func describesXML(v any) bool {
+	if _, ok := v.(xml.Marshaler); ok {
+		return true
+	}
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	return value.Kind() == reflect.Struct
+}
+
+func encodeYAML(v any) ([]byte, error) {
+	normalized, err := normalizeJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(normalized)
+}
+
+func encodeCBOR(v any) ([]byte, error) {
+	normalized, err := normalizeJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	return cbor.Marshal(normalized)
+}
+
+func encodeMessagePack(v any) ([]byte, error) {
+	normalized, err := normalizeJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.UseCompactInts(true)
+	if err := enc.Encode(normalized); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeText(v any) ([]byte, error) {
+	var text string
+	switch value := v.(type) {
+	case string:
+		text = value
+	case []byte:
+		text = string(value)
+	case fmt.Stringer:
+		text = value.String()
+	case encoding.TextMarshaler:
+		data, err := value.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	default:
+		return nil, fmt.Errorf("%w: %T as text/plain", ErrUnsupportedValue, v)
+	}
+	return withTrailingNewline([]byte(text)), nil
+}
+
+func withTrailingNewline(data []byte) []byte {
+	if len(data) == 0 || data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	return data
+}
+
+// normalizeJSON round-trips v through its JSON representation so that formats
+// without struct tag support share the JSON field names, omitempty rules, and
+// custom marshalers.
+func normalizeJSON(v any) (any, error) {
+	data, err := jsonutil.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := jsonutil.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return restoreIntegers(generic), nil
+}
+
+// restoreIntegers converts integral float64 values produced by JSON decoding
+// back into int64 so binary formats keep their compact integer encodings.
+func restoreIntegers(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, item := range value {
+			value[key] = restoreIntegers(item)
+		}
+		return value
+	case []any:
+		for idx, item := range value {
+			value[idx] = restoreIntegers(item)
+		}
+		return value
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < 1<<53 {
+			return int64(value)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+func mediaTypeEssence(mediaType string) string {
+	essence, _, _ := strings.Cut(mediaType, ";")
+	return strings.ToLower(strings.TrimSpace(essence))
+}
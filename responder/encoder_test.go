@@ -0,0 +1,81 @@
+package responder
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRespondEncodesMapsAsXML(t *testing.T) {
+	r := NewResponder(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	type project struct {
+		Name   string            `json:"name" xml:"name"`
+		Labels map[string]string `json:"labels"`
+	}
+	type tag struct {
+		Name string `xml:"name,attr"`
+	}
+
+	cases := []struct {
+		name    string
+		payload any
+		want    string
+	}{
+		{name: "map", payload: map[string]any{"name": "weaver", "tags": []string{"a", "b"}}, want: "<response><name>weaver</name><tags><i>a</i><i>b</i></tags></response>"},
+		{name: "struct with map", payload: project{Name: "weaver", Labels: map[string]string{"team": "core"}}, want: "<response><labels><team>core</team></labels><name>weaver</name></response>"},
+		{name: "struct", payload: tag{Name: "weaver"}, want: `<tag name="weaver"></tag>`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/projects", nil)
+			req.Header.Set("Accept", "application/xml")
+			rec := httptest.NewRecorder()
+			r.Respond(rec, req, http.StatusOK, tc.payload)
+
+			if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != xmlContentType {
+				t.Fatalf("unexpected response: %d %q (body %s)", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tc.want) {
+				t.Fatalf("unexpected body: got %s want %s", rec.Body.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestRespondSkipsEncodersThatCannotRepresentThePayload(t *testing.T) {
+	r := NewResponder(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	type project struct {
+		Name string `json:"name"`
+	}
+
+	cases := []struct {
+		name        string
+		accept      string
+		payload     any
+		status      int
+		contentType string
+		body        string
+	}{
+		{name: "string", accept: "text/plain", payload: "weaver", status: http.StatusOK, contentType: textContentType, body: "weaver\n"},
+		{name: "struct falls back", accept: "text/plain, application/json;q=0.5", payload: project{Name: "weaver"}, status: http.StatusOK, contentType: jsonContentType, body: "{\"name\":\"weaver\"}\n"},
+		{name: "struct not acceptable", accept: "text/plain", payload: project{Name: "weaver"}, status: http.StatusNotAcceptable, contentType: textContentType},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/projects", nil)
+			req.Header.Set("Accept", tc.accept)
+			rec := httptest.NewRecorder()
+			r.Respond(rec, req, http.StatusOK, tc.payload)
+
+			if rec.Code != tc.status || rec.Header().Get("Content-Type") != tc.contentType {
+				t.Fatalf("unexpected response: got %d %q want %d %q", rec.Code, rec.Header().Get("Content-Type"), tc.status, tc.contentType)
+			}
+			if tc.body != "" && rec.Body.String() != tc.body {
+				t.Fatalf("unexpected body: got %q want %q", rec.Body.String(), tc.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package responder
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LastModifier is implemented by payloads that know when their content last
+// changed. With WithETags enabled the timestamp is sent as Last-Modified and
+// compared against If-Modified-Since.
+type LastModifier interface {
+	LastModified() time.Time
+}
+
+// WithETags enables conditional GET support for RespondWithJSON and Respond.
+// Successful GET and HEAD responses carry a strong ETag computed from the
+// encoded body (unless the handler already set one), and requests whose
+// If-None-Match or If-Modified-Since validators still match receive 304 Not
+// Modified without a body.
+func WithETags() ResponderOption {
+	return func(r *Responder) {
+		r.etags = true
+	}
+}
+
+// writeNotModified sets the validators for a cacheable response and reports
+// whether the request's conditional headers allow answering 304 instead of
+// sending the body.
+func (r *Responder) writeNotModified(w http.ResponseWriter, req *http.Request, status int, contentType string, body []byte, payload any) bool {
+	if !r.etags || req == nil || status != http.StatusOK || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return false
+	}
+
+	header := w.Header()
+	etag := header.Get("ETag")
+	if etag == "" {
+		etag = computeETag(contentType, body)
+		header.Set("ETag", etag)
+	}
+
+	var lastModified time.Time
+	if modifier, ok := payload.(LastModifier); ok {
+		lastModified = modifier.LastModified().UTC().Truncate(time.Second)
+		if !lastModified.IsZero() {
+			header.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		}
+	}
+
+	if !notModified(req, etag, lastModified) {
+		return false
+	}
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// notModified evaluates If-None-Match and, only when it is absent,
+// If-Modified-Since as described in RFC 9110 section 13.2.2.
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		return etagListMatches(inm, etag, false)
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(since)
+}
+
+// computeETag derives a strong entity tag from the representation. The
+// content type takes part in the hash so negotiated variants never share a
+// validator.
+func computeETag(contentType string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(contentType))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	sum := hash.Sum(nil)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:18]) + `"`
+}
+
+// etagListMatches reports whether the comma separated entity-tag list in a
+// conditional header matches etag. "*" matches any current representation.
+// Weak comparison ignores the W/ prefix; strong comparison rejects weak tags.
+func etagListMatches(list, etag string, strong bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return etag != ""
+	}
+	if strong && isWeakETag(etag) {
+		return false
+	}
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong && isWeakETag(candidate) {
+			continue
+		}
+		if candidate != "" && strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isWeakETag(etag string) bool {
+	return strings.HasPrefix(etag, "W/")
+}
@@ -1,12 +1,21 @@
 package responder_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"iter"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
 
 	"github.com/drblury/apiweaver/responder"
 )
@@ -84,3 +93,732 @@ func ExampleWithStatusMetadata() {
 	// 401
 	// true
 }
+
+func ExampleResponder_Respond() {
+	type project struct {
+		Name string `json:"name" xml:"name"`
+	}
+	r := responder.NewResponder()
+
+	for _, accept := range []string{"application/xml", "application/yaml;q=0.9, application/json;q=0.5"} {
+		req := httptest.NewRequest(http.MethodGet, "/projects/weaver", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		r.Respond(rec, req, http.StatusOK, project{Name: "weaver"})
+
+		fmt.Println(rec.Code, rec.Header().Get("Content-Type"))
+		fmt.Println(strings.TrimSpace(rec.Body.String()))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/projects/weaver", nil)
+	req.Header.Set("Accept", "image/png")
+	rec := httptest.NewRecorder()
+	r.Respond(rec, req, http.StatusOK, project{Name: "weaver"})
+
+	var problem responder.ProblemDetails
+	_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+	fmt.Println(rec.Code, rec.Header().Get("Content-Type"))
+	fmt.Println(problem.Title)
+
+	// Output:
+	// 200 application/xml
+	// <?xml version="1.0" encoding="UTF-8"?>
+	// <project><name>weaver</name></project>
+	// 200 application/yaml
+	// name: weaver
+	// 406 application/problem+json
+	// Not Acceptable
+}
+
+func ExampleResponder_HandleAPIError_xml() {
+	r := responder.NewResponder()
+
+	req := httptest.NewRequest(http.MethodGet, "/projects/missing", nil)
+	req.Header.Set("Accept", "application/problem+xml")
+	rec := httptest.NewRecorder()
+	r.HandleAPIError(rec, req, http.StatusNotFound, errors.New("project missing"))
+
+	fmt.Println(rec.Header().Get("Content-Type"))
+	fmt.Println(strings.Contains(rec.Body.String(), `<problem xmlns="urn:ietf:rfc:7807">`))
+	fmt.Println(strings.Contains(rec.Body.String(), "<status>404</status>"))
+
+	// Output:
+	// application/problem+xml
+	// true
+	// true
+}
+
+func ExampleValidationError() {
+	r := responder.NewResponder()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		}
+		if !r.ReadRequestBody(w, req, &body) {
+			return
+		}
+
+		invalid := responder.NewValidationError()
+		if body.Name == "" {
+			invalid.AddPointer("/name", "required", "name is required")
+		}
+		if !strings.Contains(body.Email, "@") {
+			invalid.AddPointer("/email", "format", "email must contain @")
+		}
+		if err := invalid.Err(); err != nil {
+			r.HandleErrors(w, req, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"email":"nope"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var problem responder.ProblemDetails
+	_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+	fmt.Println(problem.Status)
+	for _, fieldErr := range problem.Errors {
+		fmt.Println(fieldErr.Pointer, fieldErr.Code, fieldErr.Message)
+	}
+
+	// Output:
+	// 400
+	// /name required name is required
+	// /email format email must contain @
+}
+
+func ExampleWithProblemEnricher() {
+	r := responder.NewResponder(
+		responder.WithProblemEnricher(func(req *http.Request, _ error, problem *responder.ProblemDetails) {
+			problem.SetExtension("tenant", req.Header.Get("X-Tenant"))
+			if problem.Status == http.StatusTooManyRequests {
+				problem.SetExtension("retryAfterSeconds", 30)
+			}
+		}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/reports", nil)
+	req.Header.Set("X-Tenant", "acme")
+	rec := httptest.NewRecorder()
+	r.HandleAPIError(rec, req, http.StatusTooManyRequests, errors.New("quota exhausted"))
+
+	var body map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	fmt.Println(body["tenant"], body["retryAfterSeconds"])
+
+	var problem responder.ProblemDetails
+	_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+	fmt.Println(problem.Title, problem.Extensions["tenant"])
+
+	// Output:
+	// acme 30
+	// Too Many Requests acme
+}
+
+func ExampleNotFound() {
+	errProjectMissing := errors.New("project not found")
+	findProject := func(name string) error {
+		// Domain code declares the HTTP semantics next to the failure.
+		return fmt.Errorf("lookup %q: %w", name, responder.NotFound(errProjectMissing).
+			WithType("https://errors.example.com/project-not-found").
+			WithDetail("no project named "+name).
+			WithExtension("project", name))
+	}
+
+	r := responder.NewResponder()
+	req := httptest.NewRequest(http.MethodGet, "/projects/loom", nil)
+	rec := httptest.NewRecorder()
+
+	err := findProject("loom")
+	r.HandleErrors(rec, req, err)
+
+	var problem responder.ProblemDetails
+	_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+	fmt.Println(problem.Status, problem.Type)
+	fmt.Println(problem.Detail, problem.Extensions["project"])
+	fmt.Println(errors.Is(err, errProjectMissing))
+
+	// Output:
+	// 404 https://errors.example.com/project-not-found
+	// no project named loom loom
+	// true
+}
+
+func ExampleResponder_TraceIDMiddleware() {
+	r := responder.NewResponder()
+	handler := r.TraceIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.HandleErrors(w, req, responder.Conflict(errors.New("already archived")))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/projects/weaver/archive", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var problem responder.ProblemDetails
+	_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+	fmt.Println(rec.Header().Get("X-Request-ID"))
+	fmt.Println(problem.TraceID)
+
+	// Output:
+	// 4bf92f3577b34da6a3ce929d0e0e4736
+	// 4bf92f3577b34da6a3ce929d0e0e4736
+}
+
+func ExampleWithTraceIDGenerator() {
+	r := responder.NewResponder(responder.WithTraceIDGenerator(responder.GenerateUUIDv7))
+
+	rec := httptest.NewRecorder()
+	r.HandleAPIError(rec, httptest.NewRequest(http.MethodGet, "/jobs/7", nil), http.StatusNotFound, errors.New("job missing"))
+
+	traceID := rec.Header().Get("X-Request-ID")
+	fmt.Println(len(traceID), traceID[14:15])
+
+	// Output:
+	// 36 7
+}
+
+func ExampleWithRedactionPolicy() {
+	errQuotaExceeded := errors.New("storage quota exceeded")
+	r := responder.NewResponder(
+		responder.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		responder.WithRedactionPolicy(responder.RedactionPolicy{
+			Detail:       "internal error",
+			PublicErrors: []responder.PublicErrorFunc{responder.PublicErrors(errQuotaExceeded)},
+		}),
+	)
+
+	for _, err := range []error{
+		errors.New(`pq: relation "accounts" does not exist`),
+		fmt.Errorf("upload: %w", errQuotaExceeded),
+		responder.ServiceUnavailable(errors.New("dial tcp 10.0.0.7:5432: refused")).WithDetail("database maintenance"),
+	} {
+		rec := httptest.NewRecorder()
+		r.HandleErrors(rec, httptest.NewRequest(http.MethodPost, "/uploads", nil), err)
+
+		var problem responder.ProblemDetails
+		_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+		fmt.Println(problem.Status, problem.Detail)
+	}
+
+	// Output:
+	// 500 internal error
+	// 500 upload: storage quota exceeded
+	// 503 database maintenance
+}
+
+type renameProjectRequest struct {
+	ID     int    `path:"id"`
+	DryRun bool   `query:"dryRun"`
+	Name   string `json:"name"`
+}
+
+func (r renameProjectRequest) Validate() error {
+	invalid := responder.NewValidationError()
+	if r.Name == "" {
+		invalid.AddPointer("/name", "required", "name is required")
+	}
+	return invalid.Err()
+}
+
+func ExampleHandle() {
+	r := responder.NewResponder()
+
+	rename := func(_ context.Context, in renameProjectRequest) (map[string]any, error) {
+		if in.ID == 404 {
+			return nil, responder.NotFound(errors.New("project not found"))
+		}
+		return map[string]any{"id": in.ID, "name": in.Name, "dryRun": in.DryRun}, nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("PUT /projects/{id}", responder.Handle(r, rename))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/projects/7?dryRun=true", strings.NewReader(`{"name":"loom"}`)))
+	fmt.Println(rec.Code, strings.TrimSpace(rec.Body.String()))
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/projects/seven", strings.NewReader(`{"name":"loom"}`)))
+	var problem responder.ProblemDetails
+	_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+	fmt.Println(rec.Code, problem.Errors[0].Field, problem.Errors[0].Message)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/projects/404", strings.NewReader(`{"name":"loom"}`)))
+	fmt.Println(rec.Code)
+
+	// Output:
+	// 200 {"dryRun":true,"id":7,"name":"loom"}
+	// 400 id "seven" is not a valid int
+	// 404
+}
+
+func ExampleWithDecodeOptions() {
+	r := responder.NewResponder(
+		responder.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		responder.WithDecodeOptions(
+			responder.WithMaxBodyBytes(64),
+			responder.WithRequiredContentType("application/json"),
+			responder.WithDisallowUnknownFields(),
+			responder.WithSingleValue(),
+		),
+	)
+
+	for _, tc := range []struct{ contentType, body string }{
+		{"application/json", `{"name":"weaver"}`},
+		{"text/csv", `name\nweaver`},
+		{"application/json", `{"name":"weaver","admin":true}`},
+		{"application/json", `{"name":"weaver"} {"name":"loom"}`},
+		{"application/json", `{"name":"` + strings.Repeat("w", 100) + `"}`},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/projects", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		rec := httptest.NewRecorder()
+
+		var body struct {
+			Name string `json:"name"`
+		}
+		fmt.Println(r.ReadRequestBody(rec, req, &body), rec.Code)
+	}
+
+	// Output:
+	// true 200
+	// false 415
+	// false 400
+	// false 400
+	// false 413
+}
+
+func ExampleResponder_Bind() {
+	type Paging struct {
+		Limit *int `query:"limit"`
+	}
+	type listEventsParams struct {
+		Paging
+		Project string    `path:"project"`
+		Kinds   []string  `query:"kind"`
+		Since   time.Time `query:"since" format:"2006-01-02"`
+		Tenant  string    `header:"X-Tenant"`
+		Session string    `cookie:"session"`
+	}
+
+	r := responder.NewResponder(responder.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /projects/{project}/events", func(w http.ResponseWriter, req *http.Request) {
+		var params listEventsParams
+		if !r.Bind(w, req, &params) {
+			return
+		}
+		fmt.Println(params.Project, params.Kinds, params.Since.Format(time.DateOnly), *params.Limit, params.Tenant, params.Session)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/projects/weaver/events?kind=push&kind=tag&since=2024-05-01&limit=20", nil)
+	req.Header.Set("X-Tenant", "acme")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s3cr3t"})
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/projects/weaver/events?since=yesterday&limit=many", nil))
+
+	var problem responder.ProblemDetails
+	_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+	fmt.Println(rec.Code)
+	for _, fieldErr := range problem.Errors {
+		fmt.Println(fieldErr.Field, fieldErr.Message)
+	}
+
+	// Output:
+	// weaver [push tag] 2024-05-01 20 acme s3cr3t
+	// 400
+	// limit "many" is not a valid int
+	// since "yesterday" does not match the time format 2006-01-02
+}
+
+type dashboard struct {
+	Widgets   []string  `json:"widgets"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (d dashboard) LastModified() time.Time {
+	return d.UpdatedAt
+}
+
+func ExampleWithETags() {
+	r := responder.NewResponder(responder.WithETags())
+	current := dashboard{Widgets: []string{"errors", "latency"}, UpdatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.RespondWithJSON(w, req, http.StatusOK, current)
+	})
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+	fmt.Println(first.Code, first.Header().Get("Last-Modified"))
+
+	poll := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	poll.Header.Set("If-None-Match", first.Header().Get("ETag"))
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, poll)
+	fmt.Println(second.Code, second.Body.Len())
+
+	since := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	since.Header.Set("If-Modified-Since", "Wed, 01 May 2024 11:00:00 GMT")
+	third := httptest.NewRecorder()
+	handler.ServeHTTP(third, since)
+	fmt.Println(third.Code)
+
+	// Output:
+	// 200 Wed, 01 May 2024 12:00:00 GMT
+	// 304 0
+	// 200
+}
+
+func ExampleResponder_CheckPreconditions() {
+	r := responder.NewResponder(responder.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	revision := 3
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		current := responder.Version{ETag: fmt.Sprintf("rev-%d", revision)}
+		if !r.CheckPreconditions(w, req, current, responder.WithPreconditionRequired()) {
+			return
+		}
+		revision++
+		r.SetVersionHeaders(w, responder.Version{ETag: fmt.Sprintf("rev-%d", revision)})
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, ifMatch := range []string{"", `"rev-2"`, `"rev-3"`} {
+		req := httptest.NewRequest(http.MethodPut, "/documents/1", strings.NewReader(`{}`))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		fmt.Println(rec.Code, rec.Header().Values("ETag"))
+	}
+
+	// Output:
+	// 428 []
+	// 412 []
+	// 204 ["rev-4"]
+}
+
+type project struct {
+	Name   string   `json:"name"`
+	Tags   []string `json:"tags,omitempty"`
+	Budget int64    `json:"budget"`
+}
+
+func ExampleResponder_ReadPatch() {
+	r := responder.NewResponder(responder.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	for _, tc := range []struct{ contentType, body string }{
+		{"application/merge-patch+json", `{"name":"loom","tags":null}`},
+		{"application/json-patch+json", `[{"op":"add","path":"/tags/-","value":"go"},{"op":"replace","path":"/budget","value":9007199254740993}]`},
+		{"application/json-patch+json", `[{"op":"test","path":"/name","value":"weaver"},{"op":"remove","path":"/owner"}]`},
+		{"application/json", `{"name":"loom"}`},
+	} {
+		current := project{Name: "weaver", Tags: []string{"api"}, Budget: 100}
+		req := httptest.NewRequest(http.MethodPatch, "/projects/1", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		rec := httptest.NewRecorder()
+
+		if r.ReadPatch(rec, req, &current) {
+			fmt.Printf("%+v\n", current)
+			continue
+		}
+		var problem responder.ProblemDetails
+		_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+		fmt.Println(rec.Code, rec.Header().Get("Accept-Patch"), problem.Errors)
+	}
+
+	// Output:
+	// {Name:loom Tags:[] Budget:100}
+	// {Name:weaver Tags:[api go] Budget:9007199254740993}
+	// 422  [/1: path /owner does not exist]
+	// 415 application/merge-patch+json, application/json-patch+json []
+}
+
+func ExampleResponder_RespondWithPage() {
+	r := responder.NewResponder(
+		responder.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		responder.WithPagination(responder.PaginationConfig{MaxLimit: 50, CursorSecret: []byte("example-secret")}),
+	)
+	colors := []string{"amber", "blue", "coral", "denim", "ecru"}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		page, ok := r.ReadPageParams(w, req)
+		if !ok {
+			return
+		}
+		end := min(page.Offset+page.Limit, len(colors))
+		total := len(colors)
+		r.RespondWithPage(w, req, colors[page.Offset:end], responder.PageMeta{
+			Limit:  page.Limit,
+			Offset: page.Offset,
+			Total:  &total,
+		})
+	})
+
+	for _, target := range []string{"/colors?sort=name&limit=2&offset=2", "/colors?limit=500&offset=-1"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			var problem responder.ProblemDetails
+			_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+			fmt.Println(rec.Code, problem.Errors)
+			continue
+		}
+		fmt.Println(rec.Header().Values("Link"))
+		fmt.Print(rec.Body.String())
+	}
+
+	cursor := r.EncodeCursor("id:42")
+	value, err := r.DecodeCursor(cursor)
+	fmt.Println(value, err)
+	_, err = r.DecodeCursor(strings.Replace(cursor, ".", "x.", 1))
+	fmt.Println(err)
+
+	// Output:
+	// [</colors?limit=2&offset=4&sort=name>; rel="next" </colors?limit=2&offset=0&sort=name>; rel="prev"]
+	// {"items":["coral","denim"],"pagination":{"limit":2,"offset":2,"total":5,"hasMore":true}}
+	// 400 [limit: must be between 1 and 50 offset: must be at least 0]
+	// id:42 <nil>
+	// cursor is invalid or has been tampered with
+}
+
+func ExampleResponder_SSE() {
+	r := responder.NewResponder(responder.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		stream, ok := r.SSE(w, req, responder.WithHeartbeat(15*time.Second))
+		if !ok {
+			return
+		}
+		defer stream.Close()
+
+		next := 1
+		if last, err := strconv.Atoi(stream.LastEventID()); err == nil {
+			next = last + 1
+		}
+		for id := next; id <= 3; id++ {
+			event := responder.Event{ID: strconv.Itoa(id), Event: "tick", Data: map[string]int{"value": id * 10}}
+			if id == next {
+				event.Retry = 2 * time.Second
+			}
+			if err := stream.Send(event); err != nil {
+				return
+			}
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	fmt.Println(rec.Header().Get("Content-Type"))
+	fmt.Print(rec.Body.String())
+
+	// Output:
+	// text/event-stream
+	// id: 2
+	// event: tick
+	// retry: 2000
+	// data: {"value":20}
+	//
+	// id: 3
+	// event: tick
+	// data: {"value":30}
+}
+
+func ExampleRespondStream() {
+	r := responder.NewResponder(responder.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	type row struct {
+		ID int `json:"id"`
+	}
+
+	rows := func(failAfter int) iter.Seq2[row, error] {
+		return func(yield func(row, error) bool) {
+			for id := 1; id <= 3; id++ {
+				if id > failAfter {
+					yield(row{}, responder.ServiceUnavailable(errors.New("replica lost")).WithDetail("export interrupted"))
+					return
+				}
+				if !yield(row{ID: id}, nil) {
+					return
+				}
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		accept    string
+		failAfter int
+	}{
+		{"application/json", 3},
+		{"application/x-ndjson", 2},
+		{"application/x-ndjson", 0},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/exports/rows", nil)
+		req.Header.Set("Accept", tc.accept)
+		rec := httptest.NewRecorder()
+		responder.RespondStream(r, rec, req, rows(tc.failAfter), responder.WithFlushEvery(1))
+
+		fmt.Println(rec.Code, rec.Header().Get("Content-Type"))
+		if rec.Code != http.StatusOK {
+			var problem responder.ProblemDetails
+			_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+			fmt.Println("problem:", problem.Detail)
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n") {
+			var trailer responder.StreamErrorRecord
+			if json.Unmarshal([]byte(line), &trailer) == nil && trailer.Error.Status != 0 {
+				fmt.Println("trailer:", trailer.Error.Status, trailer.Error.Detail)
+				continue
+			}
+			fmt.Println(line)
+		}
+	}
+
+	// Output:
+	// 200 application/json
+	// [{"id":1},{"id":2},{"id":3}]
+	// 200 application/x-ndjson
+	// {"id":1}
+	// {"id":2}
+	// trailer: 503 export interrupted
+	// 503 application/problem+json
+	// problem: export interrupted
+}
+
+func ExampleWithMessageCatalog() {
+	catalog := responder.NewMessageCatalog().
+		SetStatus("de", http.StatusBadRequest, responder.LocalizedMessage{
+			Title:  "Ungültige Anfrage",
+			Detail: "Die Anfrage enthält ungültige Felder.",
+		}).
+		SetFieldMessage("de", "required", "Pflichtfeld").
+		SetFieldMessage("en", "required", "is required")
+	r := responder.NewResponder(
+		responder.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		responder.WithMessageCatalog(catalog),
+	)
+
+	for _, acceptLanguage := range []string{"de-AT, en;q=0.5", "fr"} {
+		req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		rec := httptest.NewRecorder()
+		r.HandleErrors(rec, req, responder.NewValidationError().AddPointer("/email", "required", "email is required"))
+
+		var problem responder.ProblemDetails
+		_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+		fmt.Println(rec.Header().Get("Content-Language"), "|", problem.Title, "|", problem.Detail, "|", problem.Errors[0].Message)
+	}
+
+	// Output:
+	// de | Ungültige Anfrage | Die Anfrage enthält ungültige Felder. | Pflichtfeld
+	// en | Bad Request | validation failed: /email: email is required | is required
+}
+
+func ExampleProblemTypeRegistry() {
+	const base = "https://api.example.com/problems"
+	registry := responder.NewProblemTypeRegistry(responder.StatusProblemTypes(base, http.StatusNotFound)...).
+		Register(responder.ProblemType{
+			URI:         base + "/out-of-credit",
+			Title:       "You do not have enough credit",
+			Status:      http.StatusForbidden,
+			Description: "The account balance does not cover the requested operation.",
+			Extensions: map[string]*openapi3.Schema{
+				"balance": openapi3.NewIntegerSchema(),
+			},
+		})
+	r := responder.NewResponder(
+		responder.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		responder.WithProblemTypeBaseURL(base),
+		responder.WithProblemTypes(registry),
+	)
+
+	rec := httptest.NewRecorder()
+	r.HandleErrors(rec, httptest.NewRequest(http.MethodGet, "/widgets/9", nil), responder.NotFound(errors.New("widget 9 not found")))
+	var problem responder.ProblemDetails
+	_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+	fmt.Println(problem.Type, problem.Title)
+
+	rec = httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/problems/out-of-credit", nil))
+	fmt.Print(rec.Body.String())
+
+	components := registry.OpenAPIComponents()
+	names := make([]string, 0, len(components.Responses))
+	for name := range components.Responses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Println(names)
+
+	// Output:
+	// https://api.example.com/problems/404 Not Found
+	// {"type":"https://api.example.com/problems/out-of-credit","title":"You do not have enough credit","status":403,"description":"The account balance does not cover the requested operation.","extensions":{"balance":{"type":"integer"}}}
+	// [OutOfCreditProblem Status404Problem]
+}
+
+func ExampleWithErrorObserver() {
+	counts := map[int]int{}
+	r := responder.NewResponder(
+		responder.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		responder.WithErrorObserver(func(_ context.Context, problem responder.ProblemDetails, _ error) {
+			counts[problem.Status]++
+		}),
+		responder.WithErrorObserver(func(_ context.Context, problem responder.ProblemDetails, err error) {
+			fmt.Println("tracked:", problem.Status, err)
+		}, responder.WithObserverMinStatus(http.StatusInternalServerError), responder.WithObserverSampleRate(1)),
+	)
+
+	for _, err := range []error{
+		responder.NotFound(errors.New("order 7 not found")),
+		errors.New("database unavailable"),
+		responder.NotFound(errors.New("order 8 not found")),
+	} {
+		r.HandleErrors(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil), err)
+	}
+	fmt.Println(counts)
+
+	// Output:
+	// tracked: 500 database unavailable
+	// map[404:2 500:1]
+}
+
+func ExampleWithErrorFormatter() {
+	r := responder.NewResponder(
+		responder.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		responder.WithTraceIDGenerator(func() string { return "trace-1" }),
+		responder.WithErrorFormatter(responder.JSONAPIFormatter()),
+	)
+	fail := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.HandleErrors(w, req, responder.NewValidationError().AddPointer("/data/attributes/name", "required", "name is required"))
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/v2/projects", fail)
+	mux.Handle("/v1/projects", responder.ErrorFormatterMiddleware(responder.GoogleErrorFormatter())(fail))
+
+	for _, target := range []string{"/v2/projects", "/v1/projects"} {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		fmt.Println(rec.Header().Get("Content-Type"))
+		fmt.Print(rec.Body.String())
+	}
+
+	// Output:
+	// application/vnd.api+json
+	// {"errors":[{"id":"trace-1","links":{"type":"https://httpstatuses.io/400"},"status":"400","code":"required","title":"Bad Request","detail":"name is required","source":{"pointer":"/data/attributes/name"}}]}
+	// application/json
+	// {"error":{"code":400,"message":"validation failed: /data/attributes/name: name is required","status":"INVALID_ARGUMENT","details":[{"@type":"type.googleapis.com/google.rpc.ErrorInfo","reason":"400","domain":"httpstatuses.io"},{"@type":"type.googleapis.com/google.rpc.BadRequest","fieldViolations":[{"field":"/data/attributes/name","description":"name is required","reason":"required"}]},{"@type":"type.googleapis.com/google.rpc.RequestInfo","requestId":"trace-1"}]}}
+}
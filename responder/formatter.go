@@ -0,0 +1,262 @@
+package responder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	jsonAPIContentType = "application/vnd.api+json"
+	googleTypePrefix   = "type.googleapis.com/google.rpc."
+)
+
+// ErrorFormatter turns a problem document into the payload written to the
+// client. Format receives the fully built, enriched, and localized problem.
+// With an empty MediaType the payload is content negotiated like a regular
+// problem document; otherwise it is always written as JSON with MediaType.
+type ErrorFormatter struct {
+	MediaType string
+	Format    func(problem ProblemDetails) any
+}
+
+type errorFormatterContextKey struct{}
+
+// RFC9457Formatter writes problems as RFC 9457 problem details. It is the
+// default.
+func RFC9457Formatter() ErrorFormatter {
+	return ErrorFormatter{Format: func(problem ProblemDetails) any { return problem }}
+}
+
+// JSONAPIFormatter writes problems as a JSON:API errors document. Every
+// FieldError becomes its own error object whose source names the offending
+// pointer or parameter; the trace identifier is reported as the error id.
+func JSONAPIFormatter() ErrorFormatter {
+	return ErrorFormatter{MediaType: jsonAPIContentType, Format: formatJSONAPI}
+}
+
+// GoogleErrorFormatter writes problems in the Google API error shape,
+// {"error":{"code","message","status","details"}}, mapping the HTTP status to
+// the canonical status name and field errors to a google.rpc.BadRequest
+// detail.
+func GoogleErrorFormatter() ErrorFormatter {
+	return ErrorFormatter{MediaType: jsonContentType, Format: formatGoogleError}
+}
+
+// WithErrorFormatter sets the wire format of every problem written by the
+// responder. Routes can override it with ErrorFormatterMiddleware.
+func WithErrorFormatter(formatter ErrorFormatter) ResponderOption {
+	return func(r *Responder) {
+		if formatter.Format != nil {
+			r.errorFormatter = formatter
+		}
+	}
+}
+
+// ContextWithErrorFormatter returns a copy of ctx that makes the responder
+// write problems with formatter, overriding WithErrorFormatter.
+func ContextWithErrorFormatter(ctx context.Context, formatter ErrorFormatter) context.Context {
+	return context.WithValue(ctx, errorFormatterContextKey{}, formatter)
+}
+
+// ErrorFormatterMiddleware selects formatter for every request passing
+// through it, so legacy routes can keep their error shape while the rest of
+// the API uses the responder default.
+func ErrorFormatterMiddleware(formatter ErrorFormatter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if formatter.Format == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req.WithContext(ContextWithErrorFormatter(req.Context(), formatter)))
+		})
+	}
+}
+
+func (r *Responder) formatterFor(req *http.Request) ErrorFormatter {
+	if formatter, ok := requestContext(req).Value(errorFormatterContextKey{}).(ErrorFormatter); ok && formatter.Format != nil {
+		return formatter
+	}
+	if r.errorFormatter.Format != nil {
+		return r.errorFormatter
+	}
+	return RFC9457Formatter()
+}
+
+type jsonAPIDocument struct {
+	Errors []jsonAPIError `json:"errors"`
+}
+
+type jsonAPIError struct {
+	ID     string            `json:"id,omitempty"`
+	Links  map[string]string `json:"links,omitempty"`
+	Status string            `json:"status"`
+	Code   string            `json:"code,omitempty"`
+	Title  string            `json:"title,omitempty"`
+	Detail string            `json:"detail,omitempty"`
+	Source map[string]string `json:"source,omitempty"`
+	Meta   map[string]any    `json:"meta,omitempty"`
+}
+
+func formatJSONAPI(problem ProblemDetails) any {
+	base := jsonAPIError{
+		ID:     problem.TraceID,
+		Status: strconv.Itoa(problem.Status),
+		Title:  problem.Title,
+		Detail: problem.Detail,
+		Meta:   problem.Extensions,
+	}
+	if problem.Type != "" {
+		base.Links = map[string]string{"type": problem.Type}
+	}
+	if len(problem.Errors) == 0 {
+		return jsonAPIDocument{Errors: []jsonAPIError{base}}
+	}
+
+	errs := make([]jsonAPIError, 0, len(problem.Errors))
+	for _, field := range problem.Errors {
+		entry := base
+		entry.Code = field.Code
+		entry.Detail = field.Message
+		switch {
+		case field.Pointer != "":
+			entry.Source = map[string]string{"pointer": field.Pointer}
+		case field.Field != "":
+			entry.Source = map[string]string{"parameter": field.Field}
+		}
+		errs = append(errs, entry)
+	}
+	return jsonAPIDocument{Errors: errs}
+}
+
+type googleErrorDocument struct {
+	Error googleError `json:"error"`
+}
+
+type googleError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+	Details []any  `json:"details,omitempty"`
+}
+
+type googleErrorInfo struct {
+	Type     string            `json:"@type"`
+	Reason   string            `json:"reason"`
+	Domain   string            `json:"domain,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type googleBadRequest struct {
+	Type            string                 `json:"@type"`
+	FieldViolations []googleFieldViolation `json:"fieldViolations"`
+}
+
+type googleFieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+type googleRequestInfo struct {
+	Type      string `json:"@type"`
+	RequestID string `json:"requestId"`
+}
+
+func formatGoogleError(problem ProblemDetails) any {
+	message := problem.Detail
+	if message == "" {
+		message = problem.Title
+	}
+	out := googleError{Code: problem.Status, Message: message, Status: googleStatus(problem.Status)}
+
+	if problem.Type != "" {
+		info := googleErrorInfo{Type: googleTypePrefix + "ErrorInfo", Reason: googleReason(problem.Type), Domain: problemDomain(problem.Type)}
+		if len(problem.Extensions) > 0 {
+			info.Metadata = make(map[string]string, len(problem.Extensions))
+			for key, value := range problem.Extensions {
+				info.Metadata[key] = fmt.Sprint(value)
+			}
+		}
+		out.Details = append(out.Details, info)
+	}
+	if len(problem.Errors) > 0 {
+		badRequest := googleBadRequest{Type: googleTypePrefix + "BadRequest"}
+		for _, field := range problem.Errors {
+			name := field.Field
+			if name == "" {
+				name = field.Pointer
+			}
+			badRequest.FieldViolations = append(badRequest.FieldViolations,
+				googleFieldViolation{Field: name, Description: field.Message, Reason: field.Code})
+		}
+		out.Details = append(out.Details, badRequest)
+	}
+	if problem.TraceID != "" {
+		out.Details = append(out.Details, googleRequestInfo{Type: googleTypePrefix + "RequestInfo", RequestID: problem.TraceID})
+	}
+	return googleErrorDocument{Error: out}
+}
+
+// googleStatuses maps HTTP statuses to the canonical google.rpc.Code names
+// following the mapping published with the Google API design guide.
+var googleStatuses = map[int]string{
+	http.StatusBadRequest:                   "INVALID_ARGUMENT",
+	http.StatusUnauthorized:                 "UNAUTHENTICATED",
+	http.StatusForbidden:                    "PERMISSION_DENIED",
+	http.StatusNotFound:                     "NOT_FOUND",
+	http.StatusConflict:                     "ABORTED",
+	http.StatusPreconditionFailed:           "FAILED_PRECONDITION",
+	http.StatusPreconditionRequired:         "FAILED_PRECONDITION",
+	http.StatusRequestedRangeNotSatisfiable: "OUT_OF_RANGE",
+	http.StatusTooManyRequests:              "RESOURCE_EXHAUSTED",
+	499:                                     "CANCELLED",
+	http.StatusNotImplemented:               "UNIMPLEMENTED",
+	http.StatusServiceUnavailable:           "UNAVAILABLE",
+	http.StatusGatewayTimeout:               "DEADLINE_EXCEEDED",
+}
+
+func googleStatus(status int) string {
+	if name, ok := googleStatuses[status]; ok {
+		return name
+	}
+	switch {
+	case status >= http.StatusInternalServerError:
+		return "INTERNAL"
+	case status >= http.StatusBadRequest:
+		return "FAILED_PRECONDITION"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// googleReason derives an UPPER_SNAKE_CASE reason from the problem type, for
+// example ".../problems/out-of-credit" becomes "OUT_OF_CREDIT".
+func googleReason(typeURI string) string {
+	var builder strings.Builder
+	separate := false
+	for _, ch := range problemAnchor(typeURI) {
+		if !unicode.IsLetter(ch) && !unicode.IsDigit(ch) {
+			separate = builder.Len() > 0
+			continue
+		}
+		if separate {
+			builder.WriteByte('_')
+			separate = false
+		}
+		builder.WriteRune(unicode.ToUpper(ch))
+	}
+	return builder.String()
+}
+
+func problemDomain(typeURI string) string {
+	_, rest, found := strings.Cut(typeURI, "://")
+	if !found {
+		return ""
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	return host
+}
@@ -0,0 +1,105 @@
+package responder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Validator is implemented by request types that check their own invariants.
+// Handle calls Validate after decoding and binding the request.
+type Validator interface {
+	Validate() error
+}
+
+// HandlerOption configures the adapter returned by Handle.
+type HandlerOption func(*handlerConfig)
+
+type handlerConfig struct {
+	successStatus int
+	decodeOptions []DecodeOption
+}
+
+// WithSuccessStatus sets the status written when the handler function
+// succeeds. The default is 200 OK; 204 No Content writes no body.
+func WithSuccessStatus(status int) HandlerOption {
+	return func(cfg *handlerConfig) {
+		if status > 0 {
+			cfg.successStatus = status
+		}
+	}
+}
+
+// WithHandlerDecodeOptions applies decode options to the request body read by
+// the adapter, on top of the responder-wide WithDecodeOptions defaults.
+func WithHandlerDecodeOptions(opts ...DecodeOption) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.decodeOptions = append(cfg.decodeOptions, opts...)
+	}
+}
+
+// Handle adapts a typed function into an http.Handler. The request body, when
+// present, is decoded into Req before path, query, header, and cookie tagged
+// fields are bound as described for Bind. Req values implementing Validator
+// are validated, errors are mapped through HandleErrors, and the result is
+// rendered with Respond.
+func Handle[Req, Resp any](r *Responder, fn func(ctx context.Context, req Req) (Resp, error), opts ...HandlerOption) http.Handler {
+	cfg := handlerConfig{successStatus: http.StatusOK}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var in Req
+		if !r.decodeHandlerRequest(w, req, &in, cfg.decodeOptions) {
+			return
+		}
+
+		out, err := fn(req.Context(), in)
+		if err != nil {
+			r.HandleErrors(w, req, err)
+			return
+		}
+
+		if cfg.successStatus == http.StatusNoContent {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		r.Respond(w, req, cfg.successStatus, out)
+	})
+}
+
+func (r *Responder) decodeHandlerRequest(w http.ResponseWriter, req *http.Request, in any, opts []DecodeOption) bool {
+	if hasRequestBody(req) && !r.ReadRequestBody(w, req, in, opts...) {
+		return false
+	}
+	if err := bindParams(req, in, allBindSources...); err != nil {
+		r.HandleErrors(w, req, err, "failed to bind request parameters")
+		return false
+	}
+	if validator, ok := in.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			r.handleValidateError(w, req, err)
+			return false
+		}
+	}
+	return true
+}
+
+// handleValidateError reports Validate failures as client errors unless the
+// error already declares its own HTTP semantics.
+func (r *Responder) handleValidateError(w http.ResponseWriter, req *http.Request, err error) {
+	var apiErr APIError
+	var validationErr *ValidationError
+	if errors.As(err, &apiErr) || errors.As(err, &validationErr) {
+		r.HandleErrors(w, req, err, "request validation failed")
+		return
+	}
+	r.HandleBadRequestError(w, req, err, "request validation failed")
+}
+
+func hasRequestBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody && req.ContentLength != 0
+}
@@ -0,0 +1,430 @@
+package responder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// patchOperation is a single RFC 6902 operation.
+type patchOperation struct {
+	op       string
+	path     []string
+	from     []string
+	value    any
+	hasValue bool
+}
+
+// mergePatch applies an RFC 7396 merge patch to a generic JSON document.
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any, len(patchObject))
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// parsePatchOperations validates an RFC 6902 document and reports every
+// malformed operation with a pointer into the patch document.
+func parsePatchOperations(document any) ([]patchOperation, error) {
+	items, ok := document.([]any)
+	if !ok {
+		return nil, NewValidationError(FieldError{Pointer: "", Code: "invalid_patch", Message: "a JSON Patch document must be an array of operations"})
+	}
+
+	invalid := NewValidationError()
+	operations := make([]patchOperation, 0, len(items))
+	for idx, item := range items {
+		operation, fieldErr := parsePatchOperation(item)
+		if fieldErr != nil {
+			fieldErr.Pointer = fmt.Sprintf("/%d%s", idx, fieldErr.Pointer)
+			invalid.Add(*fieldErr)
+			continue
+		}
+		operations = append(operations, operation)
+	}
+	if err := invalid.Err(); err != nil {
+		return nil, err
+	}
+	return operations, nil
+}
+
+func parsePatchOperation(item any) (patchOperation, *FieldError) {
+	object, ok := item.(map[string]any)
+	if !ok {
+		return patchOperation{}, &FieldError{Code: "invalid_operation", Message: "operation must be an object"}
+	}
+
+	var operation patchOperation
+	operation.op, _ = object["op"].(string)
+	switch operation.op {
+	case "add", "remove", "replace", "move", "copy", "test":
+	default:
+		return patchOperation{}, &FieldError{Pointer: "/op", Code: "invalid_operation", Message: fmt.Sprintf("unsupported op %q", operation.op)}
+	}
+
+	var fieldErr *FieldError
+	if operation.path, fieldErr = operationPointer(object, "path"); fieldErr != nil {
+		return patchOperation{}, fieldErr
+	}
+	if operation.op == "move" || operation.op == "copy" {
+		if operation.from, fieldErr = operationPointer(object, "from"); fieldErr != nil {
+			return patchOperation{}, fieldErr
+		}
+	}
+	operation.value, operation.hasValue = object["value"]
+	if !operation.hasValue && (operation.op == "add" || operation.op == "replace" || operation.op == "test") {
+		return patchOperation{}, &FieldError{Pointer: "/value", Code: "required", Message: operation.op + " requires a value"}
+	}
+	return operation, nil
+}
+
+func operationPointer(object map[string]any, member string) ([]string, *FieldError) {
+	raw, ok := object[member].(string)
+	if !ok {
+		return nil, &FieldError{Pointer: "/" + member, Code: "required", Message: member + " must be a JSON pointer string"}
+	}
+	tokens, err := parseJSONPointer(raw)
+	if err != nil {
+		return nil, &FieldError{Pointer: "/" + member, Code: "invalid_pointer", Message: err.Error()}
+	}
+	return tokens, nil
+}
+
+// applyPatchOperations applies the operations in order. A failing operation
+// aborts the whole patch, as RFC 6902 requires.
+func applyPatchOperations(document any, operations []patchOperation) (any, error) {
+	for idx, operation := range operations {
+		var err error
+		document, err = applyPatchOperation(document, operation)
+		if err != nil {
+			return nil, NewValidationError(FieldError{
+				Pointer: fmt.Sprintf("/%d", idx),
+				Code:    patchErrorCode(err),
+				Message: err.Error(),
+			})
+		}
+	}
+	return document, nil
+}
+
+func applyPatchOperation(document any, operation patchOperation) (any, error) {
+	switch operation.op {
+	case "add":
+		return patchAdd(document, operation.path, operation.value)
+	case "remove":
+		updated, _, err := patchRemove(document, operation.path)
+		return updated, err
+	case "replace":
+		return patchReplace(document, operation.path, operation.value)
+	case "move":
+		if isPointerPrefix(operation.from, operation.path) && len(operation.from) < len(operation.path) {
+			return nil, fmt.Errorf("cannot move %s into one of its children", formatJSONPointer(operation.from))
+		}
+		updated, value, err := patchRemove(document, operation.from)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(updated, operation.path, value)
+	case "copy":
+		value, err := lookupJSONPointer(document, operation.from)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(document, operation.path, deepCopyJSON(value))
+	default:
+		return document, patchTest(document, operation.path, operation.value)
+	}
+}
+
+func patchAdd(document any, path []string, value any) (any, error) {
+	return mutateJSON(document, path, value, func(parent any, key string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[key] = value
+			return container, nil
+		case []any:
+			idx, err := arrayIndex(key, len(container), true)
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[idx+1:], container[idx:])
+			container[idx] = value
+			return container, nil
+		default:
+			return nil, errPathNotFound(path)
+		}
+	})
+}
+
+func patchRemove(document any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, document, nil
+	}
+	var removed any
+	updated, err := mutateJSON(document, path, nil, func(parent any, key string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			value, ok := container[key]
+			if !ok {
+				return nil, errPathNotFound(path)
+			}
+			removed = value
+			delete(container, key)
+			return container, nil
+		case []any:
+			idx, err := arrayIndex(key, len(container), false)
+			if err != nil {
+				return nil, errPathNotFound(path)
+			}
+			removed = container[idx]
+			return append(container[:idx], container[idx+1:]...), nil
+		default:
+			return nil, errPathNotFound(path)
+		}
+	})
+	return updated, removed, err
+}
+
+func patchReplace(document any, path []string, value any) (any, error) {
+	if _, err := lookupJSONPointer(document, path); err != nil {
+		return nil, err
+	}
+	updated, _, err := patchRemove(document, path)
+	if err != nil {
+		return nil, err
+	}
+	return patchAdd(updated, path, value)
+}
+
+func patchTest(document any, path []string, expected any) error {
+	actual, err := lookupJSONPointer(document, path)
+	if err != nil {
+		return err
+	}
+	if !jsonEqual(actual, expected) {
+		return &patchTestError{path: formatJSONPointer(path)}
+	}
+	return nil
+}
+
+// mutateJSON walks to the parent of the last path token and lets apply
+// change it, rebuilding the containers on the way back so slice growth is
+// propagated to the root. An empty path replaces the whole document.
+func mutateJSON(document any, path []string, value any, apply func(parent any, key string) (any, error)) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	if len(path) == 1 {
+		return apply(document, path[0])
+	}
+
+	child, err := jsonChild(document, path[0])
+	if err != nil {
+		return nil, errPathNotFound(path)
+	}
+	updated, err := mutateJSON(child, path[1:], value, apply)
+	if err != nil {
+		return nil, err
+	}
+	switch container := document.(type) {
+	case map[string]any:
+		container[path[0]] = updated
+	case []any:
+		idx, _ := arrayIndex(path[0], len(container), false)
+		container[idx] = updated
+	}
+	return document, nil
+}
+
+func lookupJSONPointer(document any, path []string) (any, error) {
+	current := document
+	for idx, token := range path {
+		child, err := jsonChild(current, token)
+		if err != nil {
+			return nil, errPathNotFound(path[:idx+1])
+		}
+		current = child
+	}
+	return current, nil
+}
+
+func jsonChild(node any, token string) (any, error) {
+	switch container := node.(type) {
+	case map[string]any:
+		value, ok := container[token]
+		if !ok {
+			return nil, errors.New("member not found")
+		}
+		return value, nil
+	case []any:
+		idx, err := arrayIndex(token, len(container), false)
+		if err != nil {
+			return nil, err
+		}
+		return container[idx], nil
+	default:
+		return nil, errors.New("not a container")
+	}
+}
+
+// arrayIndex parses an RFC 6901 array index. "-" addresses the position after
+// the last element and is only valid when appending.
+func arrayIndex(token string, length int, appending bool) (int, error) {
+	if token == "-" && appending {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	limit := length - 1
+	if appending {
+		limit = length
+	}
+	if idx > limit {
+		return 0, fmt.Errorf("array index %d out of range", idx)
+	}
+	return idx, nil
+}
+
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for idx, token := range tokens {
+		tokens[idx] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func formatJSONPointer(tokens []string) string {
+	var builder strings.Builder
+	for _, token := range tokens {
+		builder.WriteByte('/')
+		builder.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return builder.String()
+}
+
+func isPointerPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for idx := range prefix {
+		if prefix[idx] != path[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func deepCopyJSON(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(typed))
+		for key, item := range typed {
+			copied[key] = deepCopyJSON(item)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(typed))
+		for idx, item := range typed {
+			copied[idx] = deepCopyJSON(item)
+		}
+		return copied
+	default:
+		return typed
+	}
+}
+
+// jsonEqual compares decoded JSON values, treating numbers as equal when
+// their values match regardless of how they were written.
+func jsonEqual(left, right any) bool {
+	switch typed := left.(type) {
+	case json.Number:
+		other, ok := right.(json.Number)
+		return ok && numbersEqual(typed, other)
+	case map[string]any:
+		other, ok := right.(map[string]any)
+		return ok && objectsEqual(typed, other)
+	case []any:
+		other, ok := right.([]any)
+		return ok && arraysEqual(typed, other)
+	default:
+		return left == right
+	}
+}
+
+func numbersEqual(left, right json.Number) bool {
+	leftValue, leftOK := new(big.Float).SetString(left.String())
+	rightValue, rightOK := new(big.Float).SetString(right.String())
+	return leftOK && rightOK && leftValue.Cmp(rightValue) == 0
+}
+
+func objectsEqual(left, right map[string]any) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for key, value := range left {
+		otherValue, exists := right[key]
+		if !exists || !jsonEqual(value, otherValue) {
+			return false
+		}
+	}
+	return true
+}
+
+func arraysEqual(left, right []any) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for idx := range left {
+		if !jsonEqual(left[idx], right[idx]) {
+			return false
+		}
+	}
+	return true
+}
+
+type patchTestError struct {
+	path string
+}
+
+func (e *patchTestError) Error() string {
+	return "test failed: value at " + e.path + " does not match"
+}
+
+func errPathNotFound(path []string) error {
+	return fmt.Errorf("path %s does not exist", formatJSONPointer(path))
+}
+
+func patchErrorCode(err error) string {
+	var testErr *patchTestError
+	if errors.As(err, &testErr) {
+		return "test_failed"
+	}
+	return "invalid_path"
+}
@@ -0,0 +1,212 @@
+package responder
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultLanguage = "en"
+
+// LocalizedMessage is the translated title and, optionally, detail of a
+// problem. An empty Detail keeps the detail derived from the error; the
+// public detail of an APIError is never replaced.
+type LocalizedMessage struct {
+	Title  string
+	Detail string
+}
+
+// MessageCatalog holds translated problem titles, details, and validation
+// messages. Entries are keyed by language tag plus status code, problem type
+// URI, or FieldError code. Populate the catalog before passing it to
+// WithMessageCatalog; it is not safe to modify while serving requests.
+type MessageCatalog struct {
+	fallback string
+	messages map[string]map[string]LocalizedMessage
+}
+
+// NewMessageCatalog returns an empty catalog that falls back to English.
+func NewMessageCatalog() *MessageCatalog {
+	return &MessageCatalog{
+		fallback: defaultLanguage,
+		messages: make(map[string]map[string]LocalizedMessage),
+	}
+}
+
+// WithMessageCatalog localizes problem titles, details, and validation
+// messages using the language negotiated from the Accept-Language header.
+// Problem responses then carry Vary: Accept-Language, plus Content-Language
+// when a catalog entry was applied.
+func WithMessageCatalog(catalog *MessageCatalog) ResponderOption {
+	return func(r *Responder) {
+		r.messages = catalog
+	}
+}
+
+// SetStatus registers the message used for problems with the given status.
+func (c *MessageCatalog) SetStatus(lang string, status int, msg LocalizedMessage) *MessageCatalog {
+	return c.set(lang, "status:"+strconv.Itoa(status), msg)
+}
+
+// SetType registers the message used for problems with the given type URI.
+// Type entries take precedence over status entries.
+func (c *MessageCatalog) SetType(lang, typeURI string, msg LocalizedMessage) *MessageCatalog {
+	return c.set(lang, "type:"+typeURI, msg)
+}
+
+// SetFieldMessage registers the message that replaces FieldError.Message for
+// failures with the given code, such as "required" or "out_of_range".
+func (c *MessageCatalog) SetFieldMessage(lang, code, message string) *MessageCatalog {
+	return c.set(lang, "field:"+code, LocalizedMessage{Title: message})
+}
+
+// Language negotiates the catalog language for an Accept-Language header
+// value. Ranges are tried by descending quality, then in header order; a
+// range like "de-AT" also matches a "de" catalog entry. When nothing matches,
+// the English fallback is returned.
+func (c *MessageCatalog) Language(acceptLanguage string) string {
+	for _, lang := range parseAcceptLanguage(acceptLanguage) {
+		if lang == "*" {
+			return c.fallback
+		}
+		for candidate := lang; candidate != ""; candidate = parentLanguage(candidate) {
+			if _, ok := c.messages[candidate]; ok {
+				return candidate
+			}
+		}
+	}
+	return c.fallback
+}
+
+func (c *MessageCatalog) set(lang, key string, msg LocalizedMessage) *MessageCatalog {
+	lang = normalizeLanguage(lang)
+	if lang == "" {
+		return c
+	}
+	entries, ok := c.messages[lang]
+	if !ok {
+		entries = make(map[string]LocalizedMessage)
+		c.messages[lang] = entries
+	}
+	entries[key] = msg
+	return c
+}
+
+// lookup returns the first entry found for keys in lang, then in the
+// fallback language, so missing translations degrade to English the same way
+// on every request. It also reports the language the entry was found in.
+func (c *MessageCatalog) lookup(lang string, keys ...string) (LocalizedMessage, string, bool) {
+	for _, candidate := range []string{lang, c.fallback} {
+		for _, key := range keys {
+			if msg, ok := c.messages[candidate][key]; ok {
+				return msg, candidate, true
+			}
+		}
+	}
+	return LocalizedMessage{}, "", false
+}
+
+func (r *Responder) problemLanguage(req *http.Request) (string, bool) {
+	if r.messages == nil {
+		return "", false
+	}
+	acceptLanguage := ""
+	if req != nil {
+		acceptLanguage = req.Header.Get("Accept-Language")
+	}
+	return r.messages.Language(acceptLanguage), true
+}
+
+// localizeProblem translates the problem into the negotiated language and
+// returns the Content-Language of the result: the languages of the catalog
+// entries applied, or "" when the catalog had none for the problem. A catalog
+// detail only replaces details derived from the error, never the public
+// detail of an APIError.
+func (r *Responder) localizeProblem(req *http.Request, problem *ProblemDetails, explicitDetail bool) string {
+	lang, ok := r.problemLanguage(req)
+	if !ok {
+		return ""
+	}
+
+	var applied []string
+	use := func(entryLang string) {
+		if !slices.Contains(applied, entryLang) {
+			applied = append(applied, entryLang)
+		}
+	}
+	if msg, entryLang, found := r.messages.lookup(lang, "type:"+problem.Type, "status:"+strconv.Itoa(problem.Status)); found {
+		if msg.Title != "" {
+			problem.Title = msg.Title
+			use(entryLang)
+		}
+		if msg.Detail != "" && !explicitDetail {
+			problem.Detail = msg.Detail
+			use(entryLang)
+		}
+	}
+	for idx, field := range problem.Errors {
+		if field.Code == "" {
+			continue
+		}
+		if msg, entryLang, found := r.messages.lookup(lang, "field:"+field.Code); found && msg.Title != "" {
+			problem.Errors[idx].Message = msg.Title
+			use(entryLang)
+		}
+	}
+	return strings.Join(applied, ", ")
+}
+
+// setContentLanguage marks problem responses as varying by Accept-Language
+// whenever a catalog is configured and names the language only when the body
+// was actually translated.
+func (r *Responder) setContentLanguage(w http.ResponseWriter, lang string) {
+	if w == nil || r.messages == nil {
+		return
+	}
+	addVary(w.Header(), "Accept-Language")
+	if lang != "" {
+		w.Header().Set("Content-Language", lang)
+	}
+}
+
+// parseAcceptLanguage returns the normalized language ranges of an
+// Accept-Language header ordered by descending quality. Ranges with q=0 are
+// dropped.
+func parseAcceptLanguage(header string) []string {
+	type languageRange struct {
+		tag string
+		q   float64
+	}
+
+	var ranges []languageRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := normalizeLanguage(fields[0])
+		if q := parseQuality(fields[1:]); tag != "" && q > 0 {
+			ranges = append(ranges, languageRange{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	tags := make([]string, 0, len(ranges))
+	for _, rng := range ranges {
+		tags = append(tags, rng.tag)
+	}
+	return tags
+}
+
+func normalizeLanguage(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// parentLanguage truncates a tag by one subtag as in RFC 4647 lookup, so
+// "de-ch-1996" becomes "de-ch" and "de" becomes "".
+func parentLanguage(tag string) string {
+	idx := strings.LastIndex(tag, "-")
+	if idx < 0 {
+		return ""
+	}
+	return tag[:idx]
+}
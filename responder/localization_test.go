@@ -0,0 +1,58 @@
+package responder
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMessageCatalogContentLanguage(t *testing.T) {
+	catalog := NewMessageCatalog().
+		SetStatus("de", http.StatusNotFound, LocalizedMessage{Title: "Nicht gefunden", Detail: "Die Ressource existiert nicht."}).
+		SetStatus("en", http.StatusConflict, LocalizedMessage{Title: "Conflict", Detail: "The resource changed."})
+	r := NewResponder(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithMessageCatalog(catalog),
+	)
+
+	cases := []struct {
+		name     string
+		err      error
+		language string
+		title    string
+		detail   string
+	}{
+		{name: "translated", err: NotFound(errors.New("order 7 missing")), language: "de", title: "Nicht gefunden", detail: "Die Ressource existiert nicht."},
+		{name: "explicit detail", err: NotFound(errors.New("order 7 missing")).WithDetail("Order 7 was deleted."), language: "de", title: "Nicht gefunden", detail: "Order 7 was deleted."},
+		{name: "fallback entry", err: Conflict(errors.New("stale")), language: "en", title: "Conflict", detail: "The resource changed."},
+		{name: "no entry", err: Gone(errors.New("archived")), title: "Gone", detail: "archived"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/orders/7", nil)
+			req.Header.Set("Accept-Language", "de")
+			rec := httptest.NewRecorder()
+			r.HandleErrors(rec, req, tc.err)
+
+			if got := rec.Header().Get("Content-Language"); got != tc.language {
+				t.Fatalf("unexpected Content-Language: got %q want %q", got, tc.language)
+			}
+			if got := rec.Header().Values("Vary"); !strings.Contains(strings.Join(got, ","), "Accept-Language") {
+				t.Fatal("expected Vary: Accept-Language")
+			}
+			var problem ProblemDetails
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if problem.Title != tc.title || problem.Detail != tc.detail {
+				t.Fatalf("unexpected problem: got %q / %q want %q / %q", problem.Title, problem.Detail, tc.title, tc.detail)
+			}
+		})
+	}
+}
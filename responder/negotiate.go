@@ -0,0 +1,198 @@
+package responder
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var errNoAcceptableEncoder = errors.New("no acceptable encoder")
+
+// mediaRange is a single entry of an Accept header.
+type mediaRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// Respond serialises v using the encoder negotiated from the request Accept
+// header and writes it with the supplied status code. Encoders that cannot
+// represent v are skipped in favour of the next acceptable one. Requests that
+// accept none of the registered representations of v receive a 406 problem
+// document.
+func (r *Responder) Respond(w http.ResponseWriter, req *http.Request, status int, v any) {
+	r.respond(w, req, status, v, nil)
+}
+
+// respond implements Respond. beforeWrite, when set, runs once v has been
+// encoded, so callers can add headers that only belong on a successful
+// response.
+func (r *Responder) respond(w http.ResponseWriter, req *http.Request, status int, v any, beforeWrite func(http.Header)) {
+	if w == nil {
+		return
+	}
+
+	addVary(w.Header(), "Accept")
+	body, contentType, err := r.encodeNegotiated(req, v, false)
+	switch {
+	case errors.Is(err, errNoAcceptableEncoder):
+		r.HandleAPIError(w, req, http.StatusNotAcceptable, errNotAcceptable(r.encoders), "no acceptable representation")
+		return
+	case err != nil:
+		r.encodingFailed(w, err, contentType)
+		return
+	}
+	if beforeWrite != nil {
+		beforeWrite(w.Header())
+	}
+	r.writeEncoded(w, req, status, v, body, contentType)
+}
+
+// encodeNegotiated renders v with the most preferred acceptable encoder that
+// supports it. It returns errNoAcceptableEncoder when every acceptable encoder
+// reports ErrUnsupportedValue or none is acceptable at all.
+func (r *Responder) encodeNegotiated(req *http.Request, v any, problem bool) ([]byte, string, error) {
+	for _, encoder := range r.acceptableEncoders(req, problem) {
+		body, err := encoder.Encode(v)
+		if errors.Is(err, ErrUnsupportedValue) {
+			continue
+		}
+		return body, encoder.contentType(problem), err
+	}
+	return nil, "", errNoAcceptableEncoder
+}
+
+// acceptableEncoders ranks the registered encoders that satisfy the Accept
+// header. Ties are broken by specificity and then by registry order, so the
+// registry order applies unchanged to requests without an Accept header.
+func (r *Responder) acceptableEncoders(req *http.Request, problem bool) []Encoder {
+	accept := acceptHeader(req)
+	if accept == "" {
+		return r.encoders
+	}
+
+	type candidate struct {
+		encoder     Encoder
+		q           float64
+		specificity int
+	}
+	ranges := parseAccept(accept)
+	candidates := make([]candidate, 0, len(r.encoders))
+	for _, encoder := range r.encoders {
+		if q, specificity := encoderQuality(encoder, ranges, problem); q > 0 {
+			candidates = append(candidates, candidate{encoder: encoder, q: q, specificity: specificity})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		if order := cmp.Compare(b.q, a.q); order != 0 {
+			return order
+		}
+		return cmp.Compare(b.specificity, a.specificity)
+	})
+
+	encoders := make([]Encoder, len(candidates))
+	for idx, c := range candidates {
+		encoders[idx] = c.encoder
+	}
+	return encoders
+}
+
+func encoderQuality(encoder Encoder, ranges []mediaRange, problem bool) (float64, int) {
+	candidates := []string{mediaTypeEssence(encoder.MediaType)}
+	if problem && encoder.ProblemMediaType != "" {
+		candidates = append(candidates, mediaTypeEssence(encoder.ProblemMediaType))
+	}
+
+	q, specificity := 0.0, 0
+	for _, candidate := range candidates {
+		candidateQ, candidateSpecificity := matchMediaRanges(candidate, ranges)
+		if candidateSpecificity > specificity || (candidateSpecificity == specificity && candidateQ > q) {
+			q, specificity = candidateQ, candidateSpecificity
+		}
+	}
+	return q, specificity
+}
+
+// matchMediaRanges returns the quality of the most specific range matching the
+// media type, as described by RFC 9110 section 12.5.1.
+func matchMediaRanges(mediaType string, ranges []mediaRange) (float64, int) {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, 0
+	for _, rng := range ranges {
+		current := 0
+		switch {
+		case rng.typ == typ && rng.subtype == subtype:
+			current = 3
+		case rng.typ == typ && rng.subtype == "*":
+			current = 2
+		case rng.typ == "*" && rng.subtype == "*":
+			current = 1
+		}
+		if current > specificity {
+			q, specificity = rng.q, current
+		}
+	}
+	return q, specificity
+}
+
+func parseAccept(header string) []mediaRange {
+	parts := strings.Split(header, ",")
+	ranges := make([]mediaRange, 0, len(parts))
+	for _, part := range parts {
+		fields := strings.Split(part, ";")
+		typ, subtype, ok := strings.Cut(mediaTypeEssence(fields[0]), "/")
+		if !ok || typ == "" || subtype == "" {
+			continue
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: parseQuality(fields[1:])})
+	}
+	return ranges
+}
+
+func parseQuality(params []string) float64 {
+	for _, param := range params {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || q < 0 {
+			return 0
+		}
+		if q > 1 {
+			return 1
+		}
+		return q
+	}
+	return 1
+}
+
+func acceptHeader(req *http.Request) string {
+	if req == nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.Join(req.Header.Values("Accept"), ","))
+}
+
+func addVary(header http.Header, field string) {
+	for _, value := range header.Values("Vary") {
+		for _, existing := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(existing), field) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", field)
+}
+
+func errNotAcceptable(encoders []Encoder) error {
+	available := make([]string, 0, len(encoders))
+	for _, encoder := range encoders {
+		available = append(available, mediaTypeEssence(encoder.MediaType))
+	}
+	return fmt.Errorf("none of the available representations is acceptable: %s", strings.Join(available, ", "))
+}
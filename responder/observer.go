@@ -0,0 +1,102 @@
+package responder
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+)
+
+// ErrorObserverFunc is notified about every problem the responder renders,
+// together with the error that caused it. Observers run synchronously on the
+// request goroutine after the problem is logged and before it is written, so
+// they can capture stack traces with runtime/debug.Stack; anything slow
+// should be handed off to a background worker.
+type ErrorObserverFunc func(ctx context.Context, problem ProblemDetails, err error)
+
+// ObserverOption tunes an observer registered with WithErrorObserver.
+type ObserverOption func(*errorObserver)
+
+type errorObserver struct {
+	observe    ErrorObserverFunc
+	sampleRate float64
+	minStatus  int
+}
+
+// WithErrorObserver registers an observer that sees every problem handled by
+// the responder, such as an error tracker, per-status counters, or audit
+// hooks. Observers run in registration order; a panicking observer is
+// recovered and logged so it cannot break the response.
+func WithErrorObserver(observe ErrorObserverFunc, opts ...ObserverOption) ResponderOption {
+	return func(r *Responder) {
+		if observe == nil {
+			return
+		}
+		observer := errorObserver{observe: observe, sampleRate: 1}
+		for _, opt := range opts {
+			if opt != nil {
+				opt(&observer)
+			}
+		}
+		r.errorObservers = append(r.errorObservers, observer)
+	}
+}
+
+// WithObserverSampleRate forwards only the given fraction of problems, from
+// 0 (none) to 1 (all, the default), chosen at random.
+func WithObserverSampleRate(rate float64) ObserverOption {
+	return func(o *errorObserver) {
+		o.sampleRate = min(max(rate, 0), 1)
+	}
+}
+
+// WithObserverMinStatus skips problems whose status is below status, for
+// example http.StatusInternalServerError to observe server errors only.
+func WithObserverMinStatus(status int) ObserverOption {
+	return func(o *errorObserver) {
+		o.minStatus = status
+	}
+}
+
+func (r *Responder) notifyObservers(req *http.Request, problem ProblemDetails, err error) {
+	if len(r.errorObservers) == 0 {
+		return
+	}
+
+	ctx := requestContext(req)
+	for _, observer := range r.errorObservers {
+		if !observer.samples(problem.Status) {
+			continue
+		}
+		r.runObserver(ctx, observer, problem, err)
+	}
+}
+
+func (r *Responder) runObserver(ctx context.Context, observer errorObserver, problem ProblemDetails, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			r.logger().Error("error observer panicked", "panic", fmt.Sprint(recovered), "traceId", problem.TraceID)
+		}
+	}()
+
+	// Observers receive their own copy of the field errors and extensions
+	// so they cannot alter the response.
+	problem.Errors = cloneFieldErrors(problem.Errors)
+	problem.Extensions = maps.Clone(problem.Extensions)
+	observer.observe(ctx, problem, err)
+}
+
+func (o errorObserver) samples(status int) bool {
+	if status < o.minStatus {
+		return false
+	}
+	switch {
+	case o.sampleRate >= 1:
+		return true
+	case o.sampleRate <= 0:
+		return false
+	default:
+		return rand.Float64() < o.sampleRate
+	}
+}
@@ -0,0 +1,269 @@
+package responder
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageLimit    = 20
+	defaultMaxPageLimit = 100
+	cursorSecretSize    = 32
+
+	limitParam  = "limit"
+	offsetParam = "offset"
+	cursorParam = "cursor"
+)
+
+var errInvalidCursor = errors.New("cursor is invalid or has been tampered with")
+
+// PaginationConfig bounds the page parameters accepted by ReadPageParams and
+// holds the key used to sign opaque cursors.
+type PaginationConfig struct {
+	// DefaultLimit applies when the request has no limit parameter. Zero
+	// means 20.
+	DefaultLimit int
+	// MaxLimit is the largest accepted limit. Zero means 100.
+	MaxLimit int
+	// MaxOffset is the largest accepted offset. Zero leaves offsets
+	// unbounded.
+	MaxOffset int
+	// CursorSecret signs cursors with HMAC-SHA256. Empty uses a random key
+	// generated per Responder, which invalidates cursors across restarts and
+	// between replicas; set a shared secret when either matters.
+	CursorSecret []byte
+}
+
+// PageParams are the validated pagination parameters of a request. Cursor
+// holds the verified value originally passed to RespondWithPage, not the
+// signed token the client sent.
+type PageParams struct {
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// PageMeta describes the page passed to RespondWithPage. Use Offset and Total
+// or HasMore for offset pagination, or NextCursor and PrevCursor for cursor
+// pagination. Cursors are plain values such as the last seen ID; the
+// responder signs them before they reach the client.
+type PageMeta struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+}
+
+// Page is the envelope written by RespondWithPage.
+type Page struct {
+	Items      any      `json:"items"`
+	Pagination PageMeta `json:"pagination"`
+}
+
+// WithPagination configures the limits and cursor signing key used by
+// ReadPageParams and RespondWithPage.
+func WithPagination(cfg PaginationConfig) ResponderOption {
+	return func(r *Responder) {
+		if cfg.DefaultLimit <= 0 {
+			cfg.DefaultLimit = defaultPageLimit
+		}
+		if cfg.MaxLimit <= 0 {
+			cfg.MaxLimit = defaultMaxPageLimit
+		}
+		cfg.DefaultLimit = min(cfg.DefaultLimit, cfg.MaxLimit)
+		if len(cfg.CursorSecret) == 0 {
+			cfg.CursorSecret = r.pagination.CursorSecret
+		}
+		cfg.CursorSecret = append([]byte(nil), cfg.CursorSecret...)
+		r.pagination = cfg
+	}
+}
+
+// ReadPageParams parses and validates the limit, offset, and cursor query
+// parameters. Out-of-range values, non-numeric input, tampered cursors, and
+// requests combining offset with cursor are answered with a 400 problem
+// listing every offending parameter, and ok is false.
+func (r *Responder) ReadPageParams(w http.ResponseWriter, req *http.Request) (params PageParams, ok bool) {
+	query := req.URL.Query()
+	invalid := NewValidationError()
+	params.Limit = r.pagination.DefaultLimit
+
+	if raw := query.Get(limitParam); raw != "" {
+		params.Limit = readBoundedInt(invalid, limitParam, raw, 1, r.pagination.MaxLimit, params.Limit)
+	}
+	if raw := query.Get(offsetParam); raw != "" {
+		maxOffset := r.pagination.MaxOffset
+		if maxOffset <= 0 {
+			maxOffset = math.MaxInt
+		}
+		params.Offset = readBoundedInt(invalid, offsetParam, raw, 0, maxOffset, 0)
+	}
+	if raw := query.Get(cursorParam); raw != "" {
+		cursor, err := r.DecodeCursor(raw)
+		switch {
+		case err != nil:
+			invalid.AddField(cursorParam, "invalid", err.Error())
+		case query.Has(offsetParam):
+			invalid.AddField(cursorParam, "conflict", "cursor cannot be combined with offset")
+		default:
+			params.Cursor = cursor
+		}
+	}
+
+	if err := invalid.Err(); err != nil {
+		r.HandleErrors(w, req, err, "invalid pagination parameters")
+		return PageParams{}, false
+	}
+	return params, true
+}
+
+// RespondWithPage writes items inside a Page envelope using content
+// negotiation and adds RFC 8288 Link headers for the next and previous pages.
+// Link targets reuse the request URL, so filters and sort parameters carry
+// over; only limit, offset, and cursor are rewritten. Targets are relative
+// references (path and query) that clients resolve against the request URL.
+// The headers are only added when a representation can be negotiated, so a
+// 406 problem carries no page links.
+func (r *Responder) RespondWithPage(w http.ResponseWriter, req *http.Request, items any, meta PageMeta) {
+	if w == nil {
+		return
+	}
+
+	if meta.Total != nil && meta.Offset+meta.Limit < *meta.Total {
+		meta.HasMore = true
+	}
+	if meta.NextCursor != "" {
+		meta.HasMore = true
+		meta.NextCursor = r.EncodeCursor(meta.NextCursor)
+	}
+	if meta.PrevCursor != "" {
+		meta.PrevCursor = r.EncodeCursor(meta.PrevCursor)
+	}
+
+	r.respond(w, req, http.StatusOK, Page{Items: nonNilItems(items), Pagination: meta}, func(header http.Header) {
+		for _, link := range pageLinks(req, meta) {
+			header.Add("Link", link)
+		}
+	})
+}
+
+// EncodeCursor signs value and returns an opaque, URL-safe cursor token.
+func (r *Responder) EncodeCursor(value string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(value))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(r.signCursor(payload))
+}
+
+// DecodeCursor verifies a token produced by EncodeCursor and returns the
+// original value.
+func (r *Responder) DecodeCursor(token string) (string, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found {
+		return "", errInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, r.signCursor(payload)) {
+		return "", errInvalidCursor
+	}
+	value, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", errInvalidCursor
+	}
+	return string(value), nil
+}
+
+func (r *Responder) signCursor(payload string) []byte {
+	mac := hmac.New(sha256.New, r.pagination.CursorSecret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// readBoundedInt parses raw and records a failure for param when it is not an
+// integer within [lower, upper], returning fallback in that case.
+func readBoundedInt(invalid *ValidationError, param, raw string, lower, upper, fallback int) int {
+	value, err := strconv.Atoi(raw)
+	switch {
+	case err != nil:
+		invalid.AddField(param, "invalid", fmt.Sprintf("%q is not an integer", raw))
+	case value < lower || value > upper:
+		message := fmt.Sprintf("must be between %d and %d", lower, upper)
+		if upper == math.MaxInt {
+			message = fmt.Sprintf("must be at least %d", lower)
+		}
+		invalid.AddField(param, "out_of_range", message)
+	default:
+		return value
+	}
+	return fallback
+}
+
+func defaultPaginationConfig() PaginationConfig {
+	secret := make([]byte, cursorSecretSize)
+	fillRandom(secret)
+	return PaginationConfig{
+		DefaultLimit: defaultPageLimit,
+		MaxLimit:     defaultMaxPageLimit,
+		CursorSecret: secret,
+	}
+}
+
+// pageLinks builds the Link header values for meta. Cursor pagination wins
+// when cursors are present; otherwise offsets are derived from the limit.
+func pageLinks(req *http.Request, meta PageMeta) []string {
+	if req == nil || req.URL == nil || meta.Limit <= 0 {
+		return nil
+	}
+
+	var links []string
+	switch {
+	case meta.NextCursor != "" || meta.PrevCursor != "":
+		if meta.NextCursor != "" {
+			links = append(links, pageLink(req.URL, meta.Limit, cursorParam, meta.NextCursor, "next"))
+		}
+		if meta.PrevCursor != "" {
+			links = append(links, pageLink(req.URL, meta.Limit, cursorParam, meta.PrevCursor, "prev"))
+		}
+	default:
+		if meta.HasMore {
+			links = append(links, pageLink(req.URL, meta.Limit, offsetParam, strconv.Itoa(meta.Offset+meta.Limit), "next"))
+		}
+		if meta.Offset > 0 {
+			links = append(links, pageLink(req.URL, meta.Limit, offsetParam, strconv.Itoa(max(meta.Offset-meta.Limit, 0)), "prev"))
+		}
+	}
+	return links
+}
+
+func pageLink(base *url.URL, limit int, param, value, rel string) string {
+	target := *base
+	query := target.Query()
+	query.Del(offsetParam)
+	query.Del(cursorParam)
+	query.Set(limitParam, strconv.Itoa(limit))
+	query.Set(param, value)
+	target.RawQuery = query.Encode()
+	return fmt.Sprintf("<%s>; rel=%q", target.RequestURI(), rel)
+}
+
+// nonNilItems renders nil slices as empty arrays so clients can always
+// iterate over items.
+func nonNilItems(items any) any {
+	if items == nil {
+		return []any{}
+	}
+	value := reflect.ValueOf(items)
+	if value.Kind() == reflect.Slice && value.IsNil() {
+		return reflect.MakeSlice(value.Type(), 0, 0).Interface()
+	}
+	return items
+}
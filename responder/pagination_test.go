@@ -0,0 +1,47 @@
+package responder
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestRespondWithPageLinks(t *testing.T) {
+	r := NewResponder(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	total := 50
+
+	req := httptest.NewRequest(http.MethodGet, "/orders?status=open&offset=20&limit=10", nil)
+	rec := httptest.NewRecorder()
+	r.RespondWithPage(rec, req, []int{1, 2}, PageMeta{Limit: 10, Offset: 20, Total: &total})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	want := []string{
+		`</orders?limit=10&offset=30&status=open>; rel="next"`,
+		`</orders?limit=10&offset=10&status=open>; rel="prev"`,
+	}
+	if got := rec.Header().Values("Link"); !slices.Equal(got, want) {
+		t.Fatalf("unexpected links: got %q want %q", got, want)
+	}
+}
+
+func TestRespondWithPageOmitsLinksWhenNotAcceptable(t *testing.T) {
+	r := NewResponder(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	total := 50
+
+	req := httptest.NewRequest(http.MethodGet, "/orders?limit=10", nil)
+	req.Header.Set("Accept", "text/csv")
+	rec := httptest.NewRecorder()
+	r.RespondWithPage(rec, req, []int{1, 2}, PageMeta{Limit: 10, Total: &total})
+
+	if rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("expected 406, got %d", rec.Code)
+	}
+	if links := rec.Header().Values("Link"); len(links) != 0 {
+		t.Fatalf("expected no links on a 406, got %q", links)
+	}
+}
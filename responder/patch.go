@@ -0,0 +1,176 @@
+package responder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+
+	"github.com/drblury/apiweaver/jsonutil"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+	acceptPatchHeader     = "Accept-Patch"
+)
+
+// ReadPatch applies the PATCH request body to the value pointed to by target.
+// The Content-Type selects the format: application/merge-patch+json (RFC 7396)
+// or application/json-patch+json (RFC 6902). Other content types are answered
+// with 415 and an Accept-Patch header, malformed bodies with 400, and patches
+// that contain invalid operations, address missing members, fail a test
+// operation, or produce a document that no longer fits target, such as one
+// with members target does not declare, with 422. The target is only modified
+// when the whole patch succeeds, and fields hidden from JSON keep their value.
+func (r *Responder) ReadPatch(w http.ResponseWriter, req *http.Request, target any, opts ...DecodeOption) bool {
+	var apply func(target any, patch []byte) error
+	switch contentType := mediaTypeEssence(req.Header.Get("Content-Type")); contentType {
+	case mergePatchContentType:
+		apply = ApplyMergePatch
+	case jsonPatchContentType:
+		apply = ApplyJSONPatch
+	default:
+		w.Header().Set(acceptPatchHeader, mergePatchContentType+", "+jsonPatchContentType)
+		err := fmt.Errorf("content type %q is not a supported patch format; use %s or %s",
+			contentType, mergePatchContentType, jsonPatchContentType)
+		r.HandleAPIError(w, req, http.StatusUnsupportedMediaType, err, "unsupported patch format")
+		return false
+	}
+
+	// The patch format was checked above; a responder-wide content type
+	// requirement such as application/json must not reject it again.
+	var patch json.RawMessage
+	if !r.ReadRequestBody(w, req, &patch, append(slices.Clip(opts), WithRequiredContentType())...) {
+		return false
+	}
+	if err := apply(target, patch); err != nil {
+		r.HandleErrors(w, req, err, "failed to apply patch")
+		return false
+	}
+	return true
+}
+
+// ApplyMergePatch applies an RFC 7396 JSON Merge Patch document to the value
+// pointed to by target. Object members set to null are removed and every
+// other value replaces the member wholesale. A patched document that does not
+// decode back into target is reported as a 422 APIError.
+func ApplyMergePatch(target any, patch []byte) error {
+	return patchValue(target, patch, func(document, parsed any) (any, error) {
+		return mergePatch(document, parsed), nil
+	})
+}
+
+// ApplyJSONPatch applies an RFC 6902 JSON Patch document to the value pointed
+// to by target. The add, remove, replace, move, copy, and test operations are
+// supported and run atomically: if any operation fails, target is left
+// untouched and a 422 APIError wrapping a *ValidationError is returned whose
+// pointers address the offending operations in the patch document.
+func ApplyJSONPatch(target any, patch []byte) error {
+	return patchValue(target, patch, func(document, parsed any) (any, error) {
+		operations, err := parsePatchOperations(parsed)
+		if err != nil {
+			return nil, err
+		}
+		return applyPatchOperations(document, operations)
+	})
+}
+
+func patchValue(target any, patch []byte, apply func(document, parsed any) (any, error)) error {
+	destination := reflect.ValueOf(target)
+	if destination.Kind() != reflect.Pointer || destination.IsNil() {
+		return fmt.Errorf("patch: target must be a non-nil pointer, got %T", target)
+	}
+
+	parsed, err := decodeJSONTree(patch)
+	if err != nil {
+		return BadRequest(fmt.Errorf("patch document is not valid JSON: %w", err))
+	}
+	current, err := jsonutil.Marshal(target)
+	if err != nil {
+		return fmt.Errorf("patch: encode target: %w", err)
+	}
+	document, err := decodeJSONTree(current)
+	if err != nil {
+		return fmt.Errorf("patch: decode target: %w", err)
+	}
+
+	patched, err := apply(document, parsed)
+	if err != nil {
+		return UnprocessableEntity(err)
+	}
+	return decodePatchedDocument(destination, patched)
+}
+
+// decodePatchedDocument decodes into a fresh value first so target keeps its
+// previous state when the patched document does not fit its type. Members
+// unknown to the type are rejected, and fields hidden from JSON, such as
+// unexported or json:"-" fields, keep their current value.
+func decodePatchedDocument(destination reflect.Value, patched any) error {
+	encoded, err := jsonutil.Marshal(patched)
+	if err != nil {
+		return fmt.Errorf("patch: encode result: %w", err)
+	}
+	decoded := reflect.New(destination.Elem().Type())
+	dec := jsonutil.NewDecoder(bytes.NewReader(encoded))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(decoded.Interface()); err != nil {
+		return UnprocessableEntity(NewValidationError(FieldError{
+			Code:    "invalid_result",
+			Message: "patched document does not match the resource",
+		}))
+	}
+
+	result := reflect.New(destination.Elem().Type()).Elem()
+	result.Set(destination.Elem())
+	overlayJSONFields(result, decoded.Elem())
+	destination.Elem().Set(result)
+	return nil
+}
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// overlayJSONFields copies the fields encoding/json reads from src into dst.
+// Structs without custom unmarshalers are walked field by field so dst keeps
+// the fields JSON cannot see; every other value is replaced wholesale.
+func overlayJSONFields(dst, src reflect.Value) {
+	typ := dst.Type()
+	if typ.Kind() != reflect.Struct || reflect.PointerTo(typ).Implements(jsonUnmarshalerType) ||
+		reflect.PointerTo(typ).Implements(textUnmarshalerType) {
+		dst.Set(src)
+		return
+	}
+
+	for idx := range typ.NumField() {
+		field := typ.Field(idx)
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+		// Exported fields of embedded structs are promoted even when the
+		// embedded type itself is unexported.
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			overlayJSONFields(dst.Field(idx), src.Field(idx))
+			continue
+		}
+		if field.IsExported() {
+			overlayJSONFields(dst.Field(idx), src.Field(idx))
+		}
+	}
+}
+
+// decodeJSONTree decodes data into maps, slices, and json.Number values so
+// integers survive the round trip without float64 rounding.
+func decodeJSONTree(data []byte) (any, error) {
+	reader := bytes.NewReader(data)
+	dec := jsonutil.NewDecoder(reader)
+	dec.UseNumber()
+
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return tree, ensureNoTrailingData(io.MultiReader(dec.Buffered(), reader))
+}
@@ -0,0 +1,131 @@
+package responder
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type patchTestInner struct {
+	Note   string `json:"note"`
+	hidden string
+}
+
+type patchTestResource struct {
+	patchTestInner
+	Name     string            `json:"name"`
+	Tags     []string          `json:"tags,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Owner    *patchTestInner   `json:"owner,omitempty"`
+	Internal string            `json:"-"`
+	revision int
+}
+
+func TestApplyJSONPatchOperations(t *testing.T) {
+	cases := []struct {
+		name  string
+		patch string
+		want  patchTestResource
+	}{
+		{name: "add", patch: `[{"op":"add","path":"/tags/-","value":"c"}]`, want: patchTestResource{Name: "a", Tags: []string{"a", "b", "c"}}},
+		{name: "add index", patch: `[{"op":"add","path":"/tags/0","value":"z"}]`, want: patchTestResource{Name: "a", Tags: []string{"z", "a", "b"}}},
+		{name: "remove", patch: `[{"op":"remove","path":"/tags/0"}]`, want: patchTestResource{Name: "a", Tags: []string{"b"}}},
+		{name: "replace", patch: `[{"op":"replace","path":"/name","value":"b"}]`, want: patchTestResource{Name: "b", Tags: []string{"a", "b"}}},
+		{name: "move", patch: `[{"op":"move","from":"/tags/0","path":"/name"}]`, want: patchTestResource{Name: "a", Tags: []string{"b"}}},
+		{name: "copy", patch: `[{"op":"copy","from":"/name","path":"/note"}]`, want: patchTestResource{patchTestInner: patchTestInner{Note: "a"}, Name: "a", Tags: []string{"a", "b"}}},
+		{name: "escaped pointer", patch: `[{"op":"add","path":"/labels","value":{}},{"op":"add","path":"/labels/a~1b~0c","value":"x"}]`, want: patchTestResource{Name: "a", Tags: []string{"a", "b"}, Labels: map[string]string{"a/b~c": "x"}}},
+		{name: "test passes", patch: `[{"op":"test","path":"/tags","value":["a","b"]},{"op":"replace","path":"/name","value":"b"}]`, want: patchTestResource{Name: "b", Tags: []string{"a", "b"}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := patchTestResource{Name: "a", Tags: []string{"a", "b"}}
+			if err := ApplyJSONPatch(&target, []byte(tc.patch)); err != nil {
+				t.Fatalf("apply patch: %v", err)
+			}
+			if !reflect.DeepEqual(target, tc.want) {
+				t.Fatalf("unexpected result: got %+v want %+v", target, tc.want)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchRejectsInvalidPatches(t *testing.T) {
+	cases := []struct {
+		name   string
+		patch  string
+		status int
+		code   string
+	}{
+		{name: "malformed", patch: `[`, status: http.StatusBadRequest},
+		{name: "unknown op", patch: `[{"op":"merge","path":"/name"}]`, status: http.StatusUnprocessableEntity},
+		{name: "missing member", patch: `[{"op":"remove","path":"/owner/note"}]`, status: http.StatusUnprocessableEntity},
+		{name: "index out of range", patch: `[{"op":"add","path":"/tags/5","value":"x"}]`, status: http.StatusUnprocessableEntity},
+		{name: "move into child", patch: `[{"op":"move","from":"/tags","path":"/tags/0"}]`, status: http.StatusUnprocessableEntity},
+		{name: "test fails", patch: `[{"op":"replace","path":"/name","value":"b"},{"op":"test","path":"/name","value":"a"}]`, status: http.StatusUnprocessableEntity},
+		{name: "wrong type", patch: `[{"op":"replace","path":"/name","value":5}]`, status: http.StatusUnprocessableEntity, code: "invalid_result"},
+		{name: "unknown member", patch: `[{"op":"add","path":"/typo","value":"x"}]`, status: http.StatusUnprocessableEntity, code: "invalid_result"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := patchTestResource{Name: "a", Tags: []string{"a", "b"}, revision: 3}
+			before := target
+			err := ApplyJSONPatch(&target, []byte(tc.patch))
+
+			var apiErr APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode() != tc.status {
+				t.Fatalf("expected a %d APIError, got %v", tc.status, err)
+			}
+			if tc.code != "" {
+				var validation *ValidationError
+				if !errors.As(err, &validation) || len(validation.Fields) == 0 || validation.Fields[0].Code != tc.code {
+					t.Fatalf("expected a %q field error, got %v", tc.code, err)
+				}
+			}
+			if !reflect.DeepEqual(target, before) {
+				t.Fatalf("target changed by a failed patch: got %+v want %+v", target, before)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatchKeepsFieldsHiddenFromJSON(t *testing.T) {
+	target := patchTestResource{
+		patchTestInner: patchTestInner{Note: "n", hidden: "embedded"},
+		Name:           "a",
+		Tags:           []string{"a"},
+		Owner:          &patchTestInner{Note: "o"},
+		Internal:       "server-side",
+		revision:       7,
+	}
+
+	if err := ApplyMergePatch(&target, []byte(`{"name":"b","tags":null,"note":"m"}`)); err != nil {
+		t.Fatalf("apply patch: %v", err)
+	}
+
+	want := patchTestResource{
+		patchTestInner: patchTestInner{Note: "m", hidden: "embedded"},
+		Name:           "b",
+		Owner:          &patchTestInner{Note: "o"},
+		Internal:       "server-side",
+		revision:       7,
+	}
+	if !reflect.DeepEqual(target, want) {
+		t.Fatalf("unexpected result: got %+v want %+v", target, want)
+	}
+}
+
+func TestApplyMergePatchRejectsUnknownMembers(t *testing.T) {
+	target := patchTestResource{Name: "a"}
+	err := ApplyMergePatch(&target, []byte(`{"typo":"x"}`))
+
+	var apiErr APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode() != http.StatusUnprocessableEntity {
+		t.Fatalf("expected a 422 APIError, got %v", err)
+	}
+	if target.Name != "a" {
+		t.Fatalf("target changed by a failed patch: %+v", target)
+	}
+}
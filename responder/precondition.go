@@ -0,0 +1,104 @@
+package responder
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Version identifies the current state of a resource for conditional
+// requests. ETag may be given with or without surrounding quotes; a weak tag
+// keeps its W/ prefix. The zero Version describes a resource that does not
+// exist.
+type Version struct {
+	ETag         string
+	LastModified time.Time
+}
+
+// PreconditionOption configures CheckPreconditions.
+type PreconditionOption func(*preconditionConfig)
+
+type preconditionConfig struct {
+	required bool
+}
+
+// WithPreconditionRequired answers requests that carry neither If-Match nor
+// If-Unmodified-Since with 428 Precondition Required, forcing clients to opt
+// into lost-update protection.
+func WithPreconditionRequired() PreconditionOption {
+	return func(cfg *preconditionConfig) {
+		cfg.required = true
+	}
+}
+
+// CheckPreconditions evaluates If-Match and, when it is absent,
+// If-Unmodified-Since against the current version of the resource, following
+// RFC 9110 section 13.2.2. On failure it writes a 412 Precondition Failed (or
+// 428 Precondition Required) problem and returns false; handlers should only
+// apply their write when it returns true.
+func (r *Responder) CheckPreconditions(w http.ResponseWriter, req *http.Request, current Version, opts ...PreconditionOption) bool {
+	var cfg preconditionConfig
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	status, err := evaluatePreconditions(req, current, cfg)
+	if err == nil {
+		return true
+	}
+	r.HandleAPIError(w, req, status, err, "conditional request rejected")
+	return false
+}
+
+// SetVersionHeaders writes ETag and Last-Modified for the supplied version so
+// clients can echo them in later conditional requests. When set before
+// RespondWithJSON or Respond, the ETag also replaces the body hash computed by
+// WithETags.
+func (r *Responder) SetVersionHeaders(w http.ResponseWriter, current Version) {
+	if etag := current.entityTag(); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !current.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", current.LastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+func evaluatePreconditions(req *http.Request, current Version, cfg preconditionConfig) (int, error) {
+	ifMatch := req.Header.Get("If-Match")
+	ifUnmodifiedSince := req.Header.Get("If-Unmodified-Since")
+
+	switch {
+	case ifMatch != "":
+		if !etagListMatches(ifMatch, current.entityTag(), true) {
+			return http.StatusPreconditionFailed, errors.New("If-Match does not match the current version of the resource")
+		}
+	case ifUnmodifiedSince != "":
+		since, err := http.ParseTime(ifUnmodifiedSince)
+		if err == nil && !current.LastModified.IsZero() &&
+			current.LastModified.UTC().Truncate(time.Second).After(since) {
+			return http.StatusPreconditionFailed, errors.New("the resource was modified after If-Unmodified-Since")
+		}
+	case cfg.required:
+		return http.StatusPreconditionRequired, errors.New("this request must be conditional; send If-Match or If-Unmodified-Since")
+	}
+	return 0, nil
+}
+
+func (v Version) entityTag() string {
+	etag := strings.TrimSpace(v.ETag)
+	if etag == "" {
+		return ""
+	}
+	weak := strings.HasPrefix(etag, "W/")
+	etag = strings.TrimPrefix(etag, "W/")
+	if !strings.HasPrefix(etag, `"`) {
+		etag = `"` + etag + `"`
+	}
+	if weak {
+		return "W/" + etag
+	}
+	return etag
+}
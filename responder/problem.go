@@ -1,13 +1,23 @@
 package responder
 
 import (
+	"bytes"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sort"
 	"time"
+
+	"github.com/drblury/apiweaver/jsonutil"
 )
 
+const problemXMLNamespace = "urn:ietf:rfc:7807"
+
 // ProblemDetails aligns HTTP error responses with RFC 9457 problem documents.
+// It renders as application/problem+json by default and as
+// application/problem+xml when the client negotiates XML.
 type ProblemDetails struct {
 	Type      string `json:"type,omitempty"`
 	Title     string `json:"title"`
@@ -16,6 +26,164 @@ type ProblemDetails struct {
 	Instance  string `json:"instance,omitempty"`
 	TraceID   string `json:"traceId,omitempty"`
 	Timestamp string `json:"timestamp,omitempty"`
+	// Errors lists field-level failures, populated from a ValidationError in
+	// the handled error chain.
+	Errors []FieldError `json:"errors,omitempty"`
+	// Extensions holds additional RFC 9457 extension members. They are
+	// rendered flat into the top-level object; keys that collide with the
+	// standard members above are ignored.
+	Extensions map[string]any `json:"-"`
+}
+
+// ProblemEnricherFunc mutates a problem document before it is logged and
+// rendered. Enrichers typically add extension members derived from the request
+// or the handled error, such as tenant identifiers or retry hints.
+type ProblemEnricherFunc func(req *http.Request, err error, problem *ProblemDetails)
+
+var reservedProblemMembers = map[string]struct{}{
+	"type": {}, "title": {}, "status": {}, "detail": {}, "instance": {},
+	"traceId": {}, "timestamp": {}, "errors": {},
+}
+
+// WithProblemEnricher registers enrichers that run, in order, for every
+// problem document produced by the responder.
+func WithProblemEnricher(enrichers ...ProblemEnricherFunc) ResponderOption {
+	return func(r *Responder) {
+		for _, enricher := range enrichers {
+			if enricher != nil {
+				r.problemEnrichers = append(r.problemEnrichers, enricher)
+			}
+		}
+	}
+}
+
+// SetExtension stores an extension member, allocating the map on first use.
+func (p *ProblemDetails) SetExtension(key string, value any) {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any)
+	}
+	p.Extensions[key] = value
+}
+
+// MarshalJSON renders the standard members followed by the extension members
+// in a single flat object.
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for idx, member := range p.members() {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := jsonutil.Marshal(member.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := jsonutil.Marshal(member.value)
+		if err != nil {
+			return nil, fmt.Errorf("problem member %q: %w", member.name, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes the standard members and collects every other member
+// into Extensions.
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	type plain ProblemDetails
+	var decoded plain
+	if err := jsonutil.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	var members map[string]any
+	if err := jsonutil.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	for name := range reservedProblemMembers {
+		delete(members, name)
+	}
+	if len(members) > 0 {
+		decoded.Extensions = members
+	}
+
+	*p = ProblemDetails(decoded)
+	return nil
+}
+
+// String renders a compact, human readable summary used by the text encoder.
+func (p ProblemDetails) String() string {
+	summary := fmt.Sprintf("%d %s", p.Status, p.Title)
+	if p.Detail != "" {
+		summary += ": " + p.Detail
+	}
+	if p.TraceID != "" {
+		summary += " (traceId " + p.TraceID + ")"
+	}
+	return summary
+}
+
+// MarshalXML renders the problem using the RFC 9457 XML representation with
+// the urn:ietf:rfc:7807 namespace.
+func (p ProblemDetails) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Space: problemXMLNamespace, Local: "problem"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, member := range p.members() {
+		if err := encodeXMLMember(e, member.name, member.value); err != nil {
+			return err
+		}
+	}
+	if err := e.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+type problemMember struct {
+	name  string
+	value any
+}
+
+// members lists the populated problem members in their canonical order.
+func (p ProblemDetails) members() []problemMember {
+	members := make([]problemMember, 0, 8+len(p.Extensions))
+	appendString := func(name, value string) {
+		if value != "" {
+			members = append(members, problemMember{name: name, value: value})
+		}
+	}
+	appendString("type", p.Type)
+	members = append(members, problemMember{name: "title", value: p.Title})
+	members = append(members, problemMember{name: "status", value: p.Status})
+	appendString("detail", p.Detail)
+	appendString("instance", p.Instance)
+	appendString("traceId", p.TraceID)
+	appendString("timestamp", p.Timestamp)
+	if len(p.Errors) > 0 {
+		members = append(members, problemMember{name: "errors", value: p.Errors})
+	}
+	return append(members, p.extensionMembers()...)
+}
+
+func (p ProblemDetails) extensionMembers() []problemMember {
+	names := make([]string, 0, len(p.Extensions))
+	for name := range p.Extensions {
+		if _, reserved := reservedProblemMembers[name]; !reserved && name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	members := make([]problemMember, 0, len(names))
+	for _, name := range names {
+		members = append(members, problemMember{name: name, value: p.Extensions[name]})
+	}
+	return members
 }
 
 func (r *Responder) statusMetaFor(status int) statusMeta {
@@ -23,19 +191,47 @@ func (r *Responder) statusMetaFor(status int) statusMeta {
 	if !ok {
 		meta = statusMeta{}
 	}
-	return normalizeStatusMeta(status, meta)
+	return normalizeStatusMeta(status, meta, r.problemBaseURL)
 }
 
-func (r *Responder) buildProblemDetails(req *http.Request, status int, err error, meta statusMeta) ProblemDetails {
-	return ProblemDetails{
+// buildProblemDetails returns the problem for err together with its
+// Content-Language, which is empty unless the message catalog translated it.
+func (r *Responder) buildProblemDetails(req *http.Request, status int, err error, meta statusMeta) (ProblemDetails, string) {
+	problem := ProblemDetails{
 		Type:      meta.typeURI,
 		Title:     meta.title,
 		Status:    status,
 		Detail:    err.Error(),
 		Instance:  requestInstance(req),
-		TraceID:   newTraceID(),
+		TraceID:   r.TraceID(req),
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	}
+
+	// Redacted problems keep only the members chosen by the server: field
+	// errors and APIError extensions may echo the same internal messages.
+	redacted := r.redaction.redacts(status, err)
+	if redacted {
+		problem.Detail = r.redaction.Detail
+	}
+	var apiErr APIError
+	explicitDetail := false
+	if errors.As(err, &apiErr) {
+		applyAPIError(&problem, apiErr, redacted)
+		explicitDetail = apiErr.PublicDetail() != ""
+	}
+	r.applyProblemType(&problem, apiErr)
+	var validationErr *ValidationError
+	if !redacted && errors.As(err, &validationErr) {
+		problem.Errors = cloneFieldErrors(validationErr.Fields)
+	}
+	lang := r.localizeProblem(req, &problem, explicitDetail)
+	return problem, lang
+}
+
+func (r *Responder) enrichProblem(req *http.Request, err error, problem *ProblemDetails) {
+	for _, enricher := range r.problemEnrichers {
+		enricher(req, err, problem)
+	}
 }
 
 func (r *Responder) logProblem(req *http.Request, meta statusMeta, err error, traceID string, status int, msgs []string) {
@@ -46,7 +242,7 @@ func (r *Responder) logProblem(req *http.Request, meta statusMeta, err error, tr
 	logger.Log(requestContext(req), meta.logLevel, meta.logMsg)
 }
 
-func normalizeStatusMeta(status int, meta statusMeta) statusMeta {
+func normalizeStatusMeta(status int, meta statusMeta, baseURL string) statusMeta {
 	if meta.logLevel == 0 {
 		meta.logLevel = slog.LevelError
 	}
@@ -57,7 +253,7 @@ func normalizeStatusMeta(status int, meta statusMeta) statusMeta {
 		meta.logMsg = meta.title
 	}
 	if meta.typeURI == "" {
-		meta.typeURI = fmt.Sprintf("%s/%d", statusDocBaseURL, status)
+		meta.typeURI = fmt.Sprintf("%s/%d", baseURL, status)
 	}
 	return meta
 }
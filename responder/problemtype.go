@@ -0,0 +1,355 @@
+package responder
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/drblury/apiweaver/jsonutil"
+)
+
+//go:embed assets/problem_types.html
+var problemTypesHTML string
+
+var problemTypesTemplate = template.Must(template.New("problem-types").Parse(problemTypesHTML))
+
+const (
+	problemDetailsSchemaName = "ProblemDetails"
+	fieldErrorSchemaName     = "FieldError"
+	schemaRefPrefix          = "#/components/schemas/"
+)
+
+// ProblemType documents a problem type URI: the title and status clients can
+// expect and the extension members that accompany it.
+type ProblemType struct {
+	URI         string                      `json:"type"`
+	Title       string                      `json:"title"`
+	Status      int                         `json:"status,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Extensions  map[string]*openapi3.Schema `json:"extensions,omitempty"`
+}
+
+// ProblemTypeRegistry collects the problem types an API can return. It
+// serves them as an HTML or JSON catalog at the type URIs themselves and
+// exports them as OpenAPI components. Register every type before serving;
+// the registry is not safe to modify concurrently with reads.
+type ProblemTypeRegistry struct {
+	types []ProblemType
+	byURI map[string]int
+}
+
+// NewProblemTypeRegistry returns a registry holding the supplied types.
+func NewProblemTypeRegistry(types ...ProblemType) *ProblemTypeRegistry {
+	registry := &ProblemTypeRegistry{byURI: make(map[string]int)}
+	registry.Register(types...)
+	return registry
+}
+
+// StatusProblemTypes returns one problem type per status, named baseURL/<code>
+// and titled with the standard status text. Registering them together with
+// WithProblemTypeBaseURL(baseURL) keeps generic problems documented on the
+// API's own host.
+func StatusProblemTypes(baseURL string, statuses ...int) []ProblemType {
+	baseURL = strings.TrimRight(baseURL, "/")
+	types := make([]ProblemType, 0, len(statuses))
+	for _, status := range statuses {
+		types = append(types, ProblemType{
+			URI:    baseURL + "/" + strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Status: status,
+		})
+	}
+	return types
+}
+
+// WithProblemTypes uses the registry to title problems whose type URI it
+// knows. Titles supplied by an APIError or by WithStatusMetadata still take
+// precedence.
+func WithProblemTypes(registry *ProblemTypeRegistry) ResponderOption {
+	return func(r *Responder) {
+		r.problemTypes = registry
+	}
+}
+
+// WithProblemTypeBaseURL replaces https://httpstatuses.io as the prefix of
+// the default problem type URIs, which become baseURL/<status>.
+func WithProblemTypeBaseURL(baseURL string) ResponderOption {
+	return func(r *Responder) {
+		if baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/"); baseURL != "" {
+			r.problemBaseURL = baseURL
+		}
+	}
+}
+
+// Register adds problem types, replacing earlier entries with the same URI.
+// Types without a URI are ignored.
+func (reg *ProblemTypeRegistry) Register(types ...ProblemType) *ProblemTypeRegistry {
+	for _, problemType := range types {
+		if problemType.URI == "" {
+			continue
+		}
+		if idx, ok := reg.byURI[problemType.URI]; ok {
+			reg.types[idx] = problemType
+			continue
+		}
+		reg.byURI[problemType.URI] = len(reg.types)
+		reg.types = append(reg.types, problemType)
+	}
+	return reg
+}
+
+// Lookup returns the problem type registered for uri.
+func (reg *ProblemTypeRegistry) Lookup(uri string) (ProblemType, bool) {
+	if reg == nil {
+		return ProblemType{}, false
+	}
+	idx, ok := reg.byURI[uri]
+	if !ok {
+		return ProblemType{}, false
+	}
+	return reg.types[idx], true
+}
+
+// Types returns the registered problem types in registration order.
+func (reg *ProblemTypeRegistry) Types() []ProblemType {
+	if reg == nil {
+		return nil
+	}
+	return append([]ProblemType(nil), reg.types...)
+}
+
+// ServeHTTP serves the catalog. A request whose path matches the path of one
+// or more type URIs receives those entries, so mounting the registry under
+// the host and prefix of the URIs makes every type dereferenceable. Paths
+// ending in a slash list the whole catalog. Clients preferring text/html get
+// a rendered page, everyone else JSON. A nil registry answers 404.
+func (reg *ProblemTypeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	types := reg.typesForPath(req.URL.Path)
+	if len(types) == 0 && strings.HasSuffix(req.URL.Path, "/") {
+		types = reg.Types()
+	}
+	if len(types) == 0 {
+		http.NotFound(w, req)
+		return
+	}
+
+	addVary(w.Header(), "Accept")
+	ranges := parseAccept(acceptHeader(req))
+	htmlQ, _ := matchMediaRanges("text/html", ranges)
+	jsonQ, _ := matchMediaRanges(jsonContentType, ranges)
+	if htmlQ > jsonQ {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := problemTypesTemplate.Execute(w, problemTypeViews(types)); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var payload any = types
+	if len(types) == 1 {
+		payload = types[0]
+	}
+	body, err := jsonutil.Marshal(payload)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", jsonContentType)
+	_, _ = w.Write(withTrailingNewline(body))
+}
+
+// OpenAPIComponents exports the registry as OpenAPI components: a shared
+// ProblemDetails and FieldError schema, plus one schema and one response per
+// problem type. Component names are derived from the last segment or the
+// fragment of each type URI, for example "OutOfCreditProblem". References are
+// pre-resolved, so the result can be merged into a loaded document and
+// validated without another pass through the loader. A nil registry exports
+// only the shared schemas.
+func (reg *ProblemTypeRegistry) OpenAPIComponents() openapi3.Components {
+	fieldError := fieldErrorSchema()
+	problemDetails := problemDetailsSchema(fieldError)
+	schemas := openapi3.Schemas{
+		fieldErrorSchemaName:     fieldError.NewRef(),
+		problemDetailsSchemaName: problemDetails.NewRef(),
+	}
+	types := reg.Types()
+	responses := make(openapi3.ResponseBodies, len(types))
+
+	for _, problemType := range types {
+		name := uniqueComponentName(schemas, problemComponentName(problemType.URI))
+		schema := problemTypeSchema(problemType, problemDetails)
+		schemas[name] = schema.NewRef()
+
+		description := problemType.Title
+		if problemType.Description != "" {
+			description = problemType.Description
+		}
+		if description == "" {
+			description = problemType.URI
+		}
+		content := openapi3.NewContentWithSchemaRef(openapi3.NewSchemaRef(schemaRefPrefix+name, schema), []string{problemContentType})
+		responses[name] = &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription(description).WithContent(content)}
+	}
+	return openapi3.Components{Schemas: schemas, Responses: responses}
+}
+
+func (r *Responder) applyProblemType(problem *ProblemDetails, apiErr APIError) {
+	problemType, ok := r.problemTypes.Lookup(problem.Type)
+	if !ok || problemType.Title == "" {
+		return
+	}
+	// Only the default status text is replaced, so explicit titles win.
+	if (apiErr == nil || apiErr.Title() == "") && problem.Title == http.StatusText(problem.Status) {
+		problem.Title = problemType.Title
+	}
+}
+
+func (reg *ProblemTypeRegistry) typesForPath(path string) []ProblemType {
+	var matches []ProblemType
+	for _, problemType := range reg.Types() {
+		parsed, err := url.Parse(problemType.URI)
+		if err == nil && parsed.Path != "" && parsed.Path == path {
+			matches = append(matches, problemType)
+		}
+	}
+	return matches
+}
+
+type problemTypeView struct {
+	ProblemType
+	Anchor     string
+	Extensions []extensionView
+}
+
+type extensionView struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+func problemTypeViews(types []ProblemType) []problemTypeView {
+	views := make([]problemTypeView, 0, len(types))
+	for _, problemType := range types {
+		view := problemTypeView{ProblemType: problemType, Anchor: problemAnchor(problemType.URI)}
+		if view.Title == "" {
+			view.Title = problemType.URI
+		}
+		names := make([]string, 0, len(problemType.Extensions))
+		for name := range problemType.Extensions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			extension := extensionView{Name: name}
+			if schema := problemType.Extensions[name]; schema != nil {
+				extension.Description = schema.Description
+				if schema.Type != nil {
+					extension.Type = strings.Join(*schema.Type, ", ")
+				}
+			}
+			view.Extensions = append(view.Extensions, extension)
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+func problemDetailsSchema(fieldError *openapi3.Schema) *openapi3.Schema {
+	schema := openapi3.NewObjectSchema().
+		WithProperty("type", openapi3.NewStringSchema().WithFormat("uri")).
+		WithProperty("title", openapi3.NewStringSchema()).
+		WithProperty("status", openapi3.NewIntegerSchema()).
+		WithProperty("detail", openapi3.NewStringSchema()).
+		WithProperty("instance", openapi3.NewStringSchema()).
+		WithProperty("traceId", openapi3.NewStringSchema()).
+		WithProperty("timestamp", openapi3.NewDateTimeSchema()).
+		WithPropertyRef("errors", &openapi3.SchemaRef{Value: &openapi3.Schema{
+			Type:  &openapi3.Types{openapi3.TypeArray},
+			Items: openapi3.NewSchemaRef(schemaRefPrefix+fieldErrorSchemaName, fieldError),
+		}})
+	schema.Required = []string{"type", "title", "status"}
+	schema.Description = "RFC 9457 problem details."
+	return schema
+}
+
+func fieldErrorSchema() *openapi3.Schema {
+	schema := openapi3.NewObjectSchema().
+		WithProperty("pointer", openapi3.NewStringSchema()).
+		WithProperty("field", openapi3.NewStringSchema()).
+		WithProperty("code", openapi3.NewStringSchema()).
+		WithProperty("message", openapi3.NewStringSchema())
+	schema.Required = []string{"message"}
+	return schema
+}
+
+func problemTypeSchema(problemType ProblemType, problemDetails *openapi3.Schema) *openapi3.Schema {
+	specific := openapi3.NewObjectSchema().
+		WithProperty("type", openapi3.NewStringSchema().WithFormat("uri").WithEnum(problemType.URI))
+	if problemType.Status > 0 {
+		specific.WithProperty("status", openapi3.NewIntegerSchema().WithEnum(problemType.Status))
+	}
+	for name, schema := range problemType.Extensions {
+		if schema != nil {
+			specific.WithProperty(name, schema)
+		}
+	}
+
+	return &openapi3.Schema{
+		Title:       problemType.Title,
+		Description: problemType.Description,
+		AllOf: openapi3.SchemaRefs{
+			openapi3.NewSchemaRef(schemaRefPrefix+problemDetailsSchemaName, problemDetails),
+			specific.NewRef(),
+		},
+	}
+}
+
+// problemComponentName turns ".../problems/out-of-credit" into
+// "OutOfCreditProblem" and ".../404" into "Status404Problem".
+func problemComponentName(uri string) string {
+	var builder strings.Builder
+	upper := true
+	for _, ch := range problemAnchor(uri) {
+		if !unicode.IsLetter(ch) && !unicode.IsDigit(ch) {
+			upper = true
+			continue
+		}
+		if upper {
+			ch = unicode.ToUpper(ch)
+			upper = false
+		}
+		builder.WriteRune(ch)
+	}
+
+	name := builder.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "Status" + name
+	}
+	return name + "Problem"
+}
+
+func uniqueComponentName(schemas openapi3.Schemas, name string) string {
+	candidate := name
+	for suffix := 2; ; suffix++ {
+		if _, exists := schemas[candidate]; !exists {
+			return candidate
+		}
+		candidate = name + strconv.Itoa(suffix)
+	}
+}
+
+// problemAnchor returns the fragment of a type URI, or its last path segment.
+func problemAnchor(uri string) string {
+	if _, fragment, found := strings.Cut(uri, "#"); found && fragment != "" {
+		return fragment
+	}
+	trimmed := strings.TrimRight(uri, "/")
+	return trimmed[strings.LastIndex(trimmed, "/")+1:]
+}
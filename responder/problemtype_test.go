@@ -0,0 +1,68 @@
+package responder
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProblemTypeTitlePrecedence(t *testing.T) {
+	const base = "https://api.example.com/problems"
+	registry := NewProblemTypeRegistry(
+		ProblemType{URI: base + "/404", Title: "Resource not found", Status: http.StatusNotFound},
+		ProblemType{URI: base + "/409", Title: "Version conflict", Status: http.StatusConflict},
+	)
+	r := NewResponder(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithProblemTypeBaseURL(base),
+		WithProblemTypes(registry),
+		WithStatusMetadata(http.StatusConflict, StatusMetadata{Title: "Edit conflict"}),
+	)
+
+	cases := []struct {
+		name  string
+		err   error
+		title string
+	}{
+		{name: "registry title", err: NotFound(errors.New("missing")), title: "Resource not found"},
+		{name: "status metadata title", err: Conflict(errors.New("stale")), title: "Edit conflict"},
+		{name: "api error title", err: NotFound(errors.New("missing")).WithTitle("Order not found"), title: "Order not found"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.HandleErrors(rec, httptest.NewRequest(http.MethodGet, "/orders/7", nil), tc.err)
+
+			var problem ProblemDetails
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if problem.Title != tc.title {
+				t.Fatalf("unexpected title: got %q want %q", problem.Title, tc.title)
+			}
+		})
+	}
+}
+
+func TestNilProblemTypeRegistry(t *testing.T) {
+	var registry *ProblemTypeRegistry
+
+	rec := httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/problems/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: got %d want %d", rec.Code, http.StatusNotFound)
+	}
+
+	components := registry.OpenAPIComponents()
+	if len(components.Responses) != 0 || components.Schemas[problemDetailsSchemaName] == nil {
+		t.Fatalf("expected only the shared schemas, got %+v", components)
+	}
+	if types := registry.Types(); types != nil {
+		t.Fatalf("expected no types, got %v", types)
+	}
+}
@@ -0,0 +1,77 @@
+package responder
+
+import (
+	"errors"
+	"net/http"
+)
+
+const defaultRedactedDetail = "The server encountered an unexpected condition. Quote the traceId when reporting this problem."
+
+// PublicErrorFunc reports whether an error's message is safe to show to
+// clients even though its status would otherwise be redacted.
+type PublicErrorFunc func(err error) bool
+
+// RedactionPolicy controls which error messages reach the public detail
+// member of problem documents. Redacted problems also omit the errors member
+// and APIError extensions, which may carry the same internal messages.
+// Redacted messages are still logged in full.
+type RedactionPolicy struct {
+	// MinStatus is the lowest status code whose detail is redacted. Zero
+	// means 500, so only server errors are hidden.
+	MinStatus int
+	// Detail replaces redacted messages. Empty uses a generic sentence that
+	// points the client at the traceId.
+	Detail string
+	// PublicErrors allowlists errors whose messages may always be shown.
+	PublicErrors []PublicErrorFunc
+}
+
+// WithRedactionPolicy hides internal error messages from problem documents,
+// typically enabled in production. Details supplied through
+// APIError.PublicDetail are considered public and never redacted.
+func WithRedactionPolicy(policy RedactionPolicy) ResponderOption {
+	return func(r *Responder) {
+		if policy.MinStatus <= 0 {
+			policy.MinStatus = http.StatusInternalServerError
+		}
+		if policy.Detail == "" {
+			policy.Detail = defaultRedactedDetail
+		}
+		policy.PublicErrors = append([]PublicErrorFunc(nil), policy.PublicErrors...)
+		r.redaction = &policy
+	}
+}
+
+// PublicErrorType allowlists every error whose chain contains a T, as found by
+// errors.As.
+func PublicErrorType[T error]() PublicErrorFunc {
+	return func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}
+}
+
+// PublicErrors allowlists errors matching any of the supplied sentinels via
+// errors.Is.
+func PublicErrors(targets ...error) PublicErrorFunc {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (p *RedactionPolicy) redacts(status int, err error) bool {
+	if p == nil || status < p.MinStatus {
+		return false
+	}
+	for _, public := range p.PublicErrors {
+		if public != nil && public(err) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package responder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedactionPolicyHidesFieldErrorsAndExtensions(t *testing.T) {
+	r := NewResponder(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithRedactionPolicy(RedactionPolicy{Detail: "internal error"}),
+	)
+
+	cases := []struct {
+		name string
+		err  error
+	}{
+		{name: "field errors", err: fmt.Errorf("import: %w", NewValidationError().AddField("query", "sql", "SELECT * FROM users failed"))},
+		{name: "extensions", err: NewStatusError(http.StatusInternalServerError, errors.New("disk full")).WithExtension("path", "/var/lib/db")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.HandleAPIError(rec, httptest.NewRequest(http.MethodPost, "/imports", nil), http.StatusInternalServerError, tc.err)
+
+			var body map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if _, ok := body["errors"]; ok {
+				t.Fatalf("redacted problem has an errors member: %s", rec.Body.String())
+			}
+			if _, ok := body["path"]; ok {
+				t.Fatalf("redacted problem has an extension: %s", rec.Body.String())
+			}
+			if body["detail"] != "internal error" {
+				t.Fatalf("unexpected detail: %v", body["detail"])
+			}
+			for _, leaked := range []string{"SELECT", "/var/lib/db", "disk full"} {
+				if strings.Contains(rec.Body.String(), leaked) {
+					t.Fatalf("redacted problem leaks %q: %s", leaked, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRedactionPolicyKeepsFieldErrorsBelowMinStatus(t *testing.T) {
+	r := NewResponder(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithRedactionPolicy(RedactionPolicy{}),
+	)
+
+	rec := httptest.NewRecorder()
+	r.HandleErrors(rec, httptest.NewRequest(http.MethodPost, "/imports", nil), NewValidationError().AddField("limit", "invalid", "not a number"))
+
+	var problem ProblemDetails
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest || len(problem.Errors) != 1 {
+		t.Fatalf("expected a 400 with one field error, got %d %s", rec.Code, rec.Body.String())
+	}
+}
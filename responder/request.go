@@ -1,37 +1,106 @@
 package responder
 
 import (
+	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/drblury/apiweaver/jsonutil"
 )
 
 // ReadRequestBody parses the request body into the provided value and handles
-// malformed content by returning a JSON error response.
-func (r *Responder) ReadRequestBody(w http.ResponseWriter, req *http.Request, v any) bool {
-	if err := r.decodeRequestBody(req, v); err != nil {
-		r.HandleBadRequestError(w, req, err, "failed to parse request body")
+// malformed content by returning a problem response. Decode options narrow
+// what is accepted; oversized bodies are answered with 413, unexpected content
+// types with 415, and every other decoding failure with 400.
+func (r *Responder) ReadRequestBody(w http.ResponseWriter, req *http.Request, v any, opts ...DecodeOption) bool {
+	if err := r.decodeRequestBody(w, req, v, opts...); err != nil {
+		status := http.StatusBadRequest
+		var apiErr APIError
+		if errors.As(err, &apiErr) {
+			status = apiErr.StatusCode()
+		}
+		r.HandleAPIError(w, req, status, err, "failed to parse request body")
 		return false
 	}
 	return true
 }
 
-func (r *Responder) decodeRequestBody(req *http.Request, v any) error {
-	if req == nil || req.Body == nil {
+func (r *Responder) decodeRequestBody(w http.ResponseWriter, req *http.Request, v any, opts ...DecodeOption) error {
+	cfg := r.decodeConfig(opts)
+	if req == nil || req.Body == nil || req.Body == http.NoBody {
+		if cfg.optionalBody {
+			return nil
+		}
 		return errors.New("request body is required")
 	}
-	if err := jsonutil.Decode(req.Body, v); err != nil {
-		if errors.Is(err, io.EOF) {
-			return io.ErrUnexpectedEOF
+
+	var body io.Reader = req.Body
+	if cfg.optionalBody {
+		// An empty optional body needs no content type, so look for the
+		// first byte before checking it.
+		buffered := bufio.NewReader(req.Body)
+		if _, err := buffered.Peek(1); errors.Is(err, io.EOF) {
+			return nil
 		}
+		body = buffered
+	}
+	if err := cfg.checkContentType(req); err != nil {
 		return err
 	}
+	if cfg.maxBytes > 0 {
+		body = http.MaxBytesReader(w, io.NopCloser(body), cfg.maxBytes)
+	}
+
+	dec := jsonutil.NewDecoder(body)
+	if cfg.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return classifyDecodeError(err, cfg)
+	}
+	if cfg.singleValue {
+		return ensureNoTrailingData(io.MultiReader(dec.Buffered(), body))
+	}
 	return nil
 }
 
+func classifyDecodeError(err error, cfg decodeConfig) error {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return NewStatusError(http.StatusRequestEntityTooLarge,
+			fmt.Errorf("request body exceeds %d bytes", tooLarge.Limit))
+	case errors.Is(err, io.EOF) && cfg.optionalBody:
+		return nil
+	case errors.Is(err, io.EOF):
+		return io.ErrUnexpectedEOF
+	default:
+		return err
+	}
+}
+
+// ensureNoTrailingData reports an error when anything but whitespace follows
+// the first JSON value.
+func ensureNoTrailingData(rest io.Reader) error {
+	buf := make([]byte, 512)
+	for {
+		n, err := rest.Read(buf)
+		if strings.Trim(string(buf[:n]), " \t\r\n") != "" {
+			return errors.New("request body must contain a single JSON value")
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return classifyDecodeError(err, decodeConfig{})
+		}
+	}
+}
+
 func requestInstance(req *http.Request) string {
 	if req == nil || req.URL == nil {
 		return ""
@@ -0,0 +1,56 @@
+package responder
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadRequestBodyOptionalBodyContentType(t *testing.T) {
+	r := NewResponder(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithDecodeOptions(WithRequiredContentType(jsonContentType)),
+	)
+
+	cases := []struct {
+		name        string
+		body        string
+		contentType string
+		opts        []DecodeOption
+		ok          bool
+		status      int
+		want        string
+	}{
+		{name: "empty optional body", opts: []DecodeOption{WithOptionalBody()}, ok: true, want: "default"},
+		{name: "optional body with content", body: `{"name":"x"}`, opts: []DecodeOption{WithOptionalBody()}, status: http.StatusUnsupportedMediaType},
+		{name: "optional body with content type", body: `{"name":"x"}`, contentType: jsonContentType, opts: []DecodeOption{WithOptionalBody()}, ok: true, want: "x"},
+		{name: "empty required body", status: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// A reader of unknown length keeps the body from becoming http.NoBody.
+			req := httptest.NewRequest(http.MethodPost, "/items", io.MultiReader(strings.NewReader(tc.body)))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rec := httptest.NewRecorder()
+			payload := struct {
+				Name string `json:"name"`
+			}{Name: "default"}
+
+			if ok := r.ReadRequestBody(rec, req, &payload, tc.opts...); ok != tc.ok {
+				t.Fatalf("unexpected result: got %v want %v (status %d, body %s)", ok, tc.ok, rec.Code, rec.Body.String())
+			}
+			if !tc.ok && rec.Code != tc.status {
+				t.Fatalf("unexpected status: got %d want %d", rec.Code, tc.status)
+			}
+			if tc.ok && payload.Name != tc.want {
+				t.Fatalf("unexpected payload: got %q want %q", payload.Name, tc.want)
+			}
+		})
+	}
+}
@@ -13,7 +13,8 @@ const (
 
 // ErrorClassifierFunc inspects an error and returns the HTTP status that should
 // be used for the response. The boolean indicates whether the error was
-// classified and prevents the generic internal server handler from running.
+// classified and prevents later classifiers and the generic internal server
+// handler from running.
 type ErrorClassifierFunc func(err error) (status int, handled bool)
 
 // ResponderOption follows the functional options pattern used by NewResponder
@@ -40,9 +41,23 @@ type StatusMetadata struct {
 // handlers. It provides structured error payloads with correlation identifiers
 // and consistent log records.
 type Responder struct {
-	log             *slog.Logger
-	statusMetadata  map[int]statusMeta
-	errorClassifier ErrorClassifierFunc
+	log                 *slog.Logger
+	statusMetadata      map[int]statusMeta
+	errorClassifiers    []ErrorClassifierFunc
+	encoders            []Encoder
+	problemEnrichers    []ProblemEnricherFunc
+	traceHeaders        []string
+	traceResponseHeader string
+	traceIDGenerator    TraceIDGenerator
+	redaction           *RedactionPolicy
+	decodeOptions       []DecodeOption
+	etags               bool
+	pagination          PaginationConfig
+	messages            *MessageCatalog
+	problemTypes        *ProblemTypeRegistry
+	problemBaseURL      string
+	errorObservers      []errorObserver
+	errorFormatter      ErrorFormatter
 }
 
 // NewResponder constructs a Responder with default status metadata and the
@@ -50,8 +65,13 @@ type Responder struct {
 // behaviours.
 func NewResponder(opts ...ResponderOption) *Responder {
 	r := &Responder{
-		log:            slog.Default(),
-		statusMetadata: defaultStatusMetadata(),
+		log:                 slog.Default(),
+		statusMetadata:      defaultStatusMetadata(),
+		encoders:            defaultEncoders(),
+		traceResponseHeader: requestIDHeader,
+		traceIDGenerator:    GenerateULID,
+		pagination:          defaultPaginationConfig(),
+		problemBaseURL:      statusDocBaseURL,
 	}
 	for _, opt := range opts {
 		if opt != nil {
@@ -71,11 +91,17 @@ func WithLogger(logger *slog.Logger) ResponderOption {
 	}
 }
 
-// WithErrorClassifier installs a classifier used by HandleErrors to derive the
-// HTTP status code from returned errors.
-func WithErrorClassifier(classifier ErrorClassifierFunc) ResponderOption {
+// WithErrorClassifier appends classifiers used by HandleErrors to derive the
+// HTTP status code from returned errors. Classifiers run in registration order
+// after APIError and ValidationError detection; the first one reporting the
+// error as handled wins.
+func WithErrorClassifier(classifiers ...ErrorClassifierFunc) ResponderOption {
 	return func(r *Responder) {
-		r.errorClassifier = classifier
+		for _, classifier := range classifiers {
+			if classifier != nil {
+				r.errorClassifiers = append(r.errorClassifiers, classifier)
+			}
+		}
 	}
 }
 
@@ -120,10 +146,12 @@ func (r *Responder) logger() *slog.Logger {
 }
 
 func (r *Responder) classifyError(err error) (int, bool) {
-	if r.errorClassifier == nil {
-		return 0, false
+	for _, classifier := range r.errorClassifiers {
+		if status, handled := classifier(err); handled {
+			return status, true
+		}
 	}
-	return r.errorClassifier(err)
+	return 0, false
 }
 
 func defaultStatusMetadata() map[int]statusMeta {
@@ -1,22 +1,23 @@
 package responder
 
 import (
+	"errors"
 	"net/http"
-
-	"github.com/drblury/apiweaver/jsonutil"
 )
 
-// HandleAPIError renders a structured JSON response for the supplied HTTP
-// status and logs the payload using the configured logger.
+// HandleAPIError renders a structured problem document for the supplied HTTP
+// status and logs the payload using the configured logger. The representation
+// is negotiated from the Accept header and defaults to
+// application/problem+json.
 func (r *Responder) HandleAPIError(w http.ResponseWriter, req *http.Request, status int, err error, logMsg ...string) {
 	if err == nil {
 		return
 	}
 
-	meta := r.statusMetaFor(status)
-	problem := r.buildProblemDetails(req, status, err, meta)
-	r.logProblem(req, meta, err, problem.TraceID, status, logMsg)
-	r.respondWithJSON(w, req, status, problem, problemContentType)
+	problem, lang := r.problemFor(req, status, err, logMsg)
+	r.echoTraceID(w, problem.TraceID)
+	r.setContentLanguage(w, lang)
+	r.respondProblem(w, req, problem)
 }
 
 // HandleInternalServerError is a shortcut that reports a 500 status code.
@@ -40,45 +41,104 @@ func (r *Responder) RespondWithJSON(w http.ResponseWriter, req *http.Request, st
 	r.respondWithJSON(w, req, status, v, jsonContentType)
 }
 
-// HandleErrors inspects the supplied error using the configured classifier and
-// emits an appropriate JSON response.
+// HandleErrors inspects the supplied error and emits an appropriate problem
+// response. An APIError anywhere in the chain supplies its own status and
+// problem members, a ValidationError is reported as 400 Bad Request with its
+// field-level errors, and anything else is passed through the configured
+// classifiers before falling back to 500 Internal Server Error.
 func (r *Responder) HandleErrors(w http.ResponseWriter, req *http.Request, err error, msgs ...string) {
 	if err == nil {
 		return
 	}
 
+	r.HandleAPIError(w, req, r.errorStatus(err), err, msgs...)
+}
+
+// errorStatus derives the response status for err in the order documented on
+// HandleErrors.
+func (r *Responder) errorStatus(err error) int {
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode()
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return http.StatusBadRequest
+	}
+
 	if status, handled := r.classifyError(err); handled {
-		r.HandleAPIError(w, req, status, err, msgs...)
-		return
+		return status
 	}
+	return http.StatusInternalServerError
+}
 
-	r.HandleInternalServerError(w, req, err, msgs...)
+// problemFor builds, enriches, logs, and reports the problem document for
+// err.
+func (r *Responder) problemFor(req *http.Request, status int, err error, logMsg []string) (ProblemDetails, string) {
+	meta := r.statusMetaFor(status)
+	problem, lang := r.buildProblemDetails(req, status, err, meta)
+	r.enrichProblem(req, err, &problem)
+	r.logProblem(req, meta, err, problem.TraceID, status, logMsg)
+	r.notifyObservers(req, problem, err)
+	return problem, lang
 }
 
 func (r *Responder) respondWithJSON(w http.ResponseWriter, req *http.Request, status int, payload any, contentType string) {
+	r.respondEncoded(w, req, status, payload, encodeJSON, resolveContentType(contentType, jsonContentType))
+}
+
+func (r *Responder) respondProblem(w http.ResponseWriter, req *http.Request, problem ProblemDetails) {
 	if w == nil {
 		return
 	}
 
-	body, err := r.marshalPayload(payload)
-	if err != nil {
-		r.logger().Error("failed to encode response", "error", err)
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	formatter := r.formatterFor(req)
+	if formatter.MediaType != "" {
+		r.respondEncoded(w, req, problem.Status, formatter.Format(problem), encodeJSON, formatter.MediaType)
 		return
 	}
 
-	r.writeResponse(w, status, resolveContentType(contentType, jsonContentType), body)
+	// Failing to render an error must never turn into another error, so
+	// problems fall back to application/problem+json.
+	addVary(w.Header(), "Accept")
+	payload := formatter.Format(problem)
+	body, contentType, err := r.encodeNegotiated(req, payload, true)
+	if errors.Is(err, errNoAcceptableEncoder) {
+		encoder := JSONEncoder()
+		body, err = encoder.Encode(payload)
+		contentType = encoder.contentType(true)
+	}
+	if err != nil {
+		r.encodingFailed(w, err, contentType)
+		return
+	}
+	r.writeEncoded(w, req, problem.Status, payload, body, contentType)
 }
 
-func (r *Responder) marshalPayload(payload any) ([]byte, error) {
-	data, err := jsonutil.Marshal(payload)
+func (r *Responder) respondEncoded(w http.ResponseWriter, req *http.Request, status int, payload any, encode EncodeFunc, contentType string) {
+	if w == nil {
+		return
+	}
+
+	body, err := encode(payload)
 	if err != nil {
-		return nil, err
+		r.encodingFailed(w, err, contentType)
+		return
 	}
-	if len(data) == 0 || data[len(data)-1] != '\n' {
-		data = append(data, '\n')
+	r.writeEncoded(w, req, status, payload, body, contentType)
+}
+
+func (r *Responder) writeEncoded(w http.ResponseWriter, req *http.Request, status int, payload any, body []byte, contentType string) {
+	if r.writeNotModified(w, req, status, contentType, body, payload) {
+		return
 	}
-	return data, nil
+	r.writeResponse(w, status, contentType, body)
+}
+
+func (r *Responder) encodingFailed(w http.ResponseWriter, err error, contentType string) {
+	r.logger().Error("failed to encode response", "error", err, "contentType", contentType)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 }
 
 func (r *Responder) writeResponse(w http.ResponseWriter, status int, contentType string, body []byte) {
@@ -0,0 +1,198 @@
+package responder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/drblury/apiweaver/jsonutil"
+)
+
+const (
+	eventStreamContentType = "text/event-stream"
+	lastEventIDHeader      = "Last-Event-ID"
+)
+
+var errSSEClosed = errors.New("sse: stream closed")
+
+// Event is a single Server-Sent Events message. Data is marshaled with
+// jsonutil; ID and Event must not contain line breaks. A positive Retry tells
+// the client how long to wait before reconnecting.
+type Event struct {
+	ID    string
+	Event string
+	Retry time.Duration
+	Data  any
+}
+
+// SSEOption tunes a stream opened with Responder.SSE.
+type SSEOption func(*sseConfig)
+
+type sseConfig struct {
+	heartbeat time.Duration
+}
+
+// WithHeartbeat sends a comment line whenever the stream has been idle for
+// interval, keeping proxies from closing quiet connections.
+func WithHeartbeat(interval time.Duration) SSEOption {
+	return func(cfg *sseConfig) {
+		cfg.heartbeat = interval
+	}
+}
+
+// SSE writes a text/event-stream response. It is safe for concurrent use;
+// every event is flushed as soon as it is written.
+type SSE struct {
+	w           http.ResponseWriter
+	controller  *http.ResponseController
+	ctx         context.Context
+	lastEventID string
+
+	mu        sync.Mutex
+	closed    bool
+	lastWrite time.Time
+	stop      chan struct{}
+	stopped   sync.WaitGroup
+}
+
+// SSE starts a Server-Sent Events stream for the request. It writes the
+// stream headers and returns a writer bound to the request context; once the
+// client disconnects, Send reports the context error. When the ResponseWriter
+// cannot flush, a 500 problem is written and ok is false. Callers should defer
+// Close so the heartbeat goroutine exits with the handler.
+func (r *Responder) SSE(w http.ResponseWriter, req *http.Request, opts ...SSEOption) (stream *SSE, ok bool) {
+	var cfg sseConfig
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	controller := http.NewResponseController(w)
+	header := w.Header()
+	header.Set("Content-Type", eventStreamContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	if err := controller.Flush(); err != nil {
+		header.Del("Cache-Control")
+		header.Del("X-Accel-Buffering")
+		r.HandleInternalServerError(w, req, fmt.Errorf("sse: response writer does not support flushing: %w", err))
+		return nil, false
+	}
+
+	stream = &SSE{
+		w:           w,
+		controller:  controller,
+		ctx:         requestContext(req),
+		lastEventID: req.Header.Get(lastEventIDHeader),
+		lastWrite:   time.Now(),
+		stop:        make(chan struct{}),
+	}
+	if cfg.heartbeat > 0 {
+		stream.stopped.Add(1)
+		go stream.heartbeat(cfg.heartbeat)
+	}
+	return stream, true
+}
+
+// LastEventID reports the Last-Event-ID header sent by a reconnecting client,
+// so handlers can replay the events it missed.
+func (s *SSE) LastEventID() string {
+	return s.lastEventID
+}
+
+// Done is closed when the client goes away.
+func (s *SSE) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Send writes and flushes a single event.
+func (s *SSE) Send(event Event) error {
+	payload, err := encodeEvent(event)
+	if err != nil {
+		return err
+	}
+	return s.write(payload)
+}
+
+// Close stops the heartbeat and rejects further events. It does not close
+// the underlying connection; returning from the handler does that.
+func (s *SSE) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.stop)
+	}
+	s.mu.Unlock()
+	s.stopped.Wait()
+}
+
+func (s *SSE) write(payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errSSEClosed
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(payload); err != nil {
+		return err
+	}
+	s.lastWrite = time.Now()
+	return s.controller.Flush()
+}
+
+func (s *SSE) heartbeat(interval time.Duration) {
+	defer s.stopped.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			idle := time.Since(s.lastWrite) >= interval
+			s.mu.Unlock()
+			if idle && s.write([]byte(": heartbeat\n\n")) != nil {
+				return
+			}
+		}
+	}
+}
+
+func encodeEvent(event Event) ([]byte, error) {
+	if strings.ContainsAny(event.ID, "\r\n\x00") || strings.ContainsAny(event.Event, "\r\n") {
+		return nil, errors.New("sse: event id and name must not contain line breaks")
+	}
+	data, err := jsonutil.Marshal(event.Data)
+	if err != nil {
+		return nil, fmt.Errorf("sse: marshal event data: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if event.ID != "" {
+		buf.WriteString("id: " + event.ID + "\n")
+	}
+	if event.Event != "" {
+		buf.WriteString("event: " + event.Event + "\n")
+	}
+	if event.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	buf.WriteString("data: ")
+	buf.Write(data)
+	buf.WriteString("\n\n")
+	return buf.Bytes(), nil
+}
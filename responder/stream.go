@@ -0,0 +1,237 @@
+package responder
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"time"
+
+	"github.com/drblury/apiweaver/jsonutil"
+)
+
+const (
+	ndjsonContentType = "application/x-ndjson"
+	jsonlContentType  = "application/jsonl"
+
+	defaultStreamFlushEvery    = 100
+	defaultStreamFlushInterval = time.Second
+)
+
+// StreamOption tunes RespondStream.
+type StreamOption func(*streamConfig)
+
+type streamConfig struct {
+	flushEvery    int
+	flushInterval time.Duration
+}
+
+// StreamErrorRecord is the trailer written by RespondStream when the sequence
+// yields an error after the first record has been sent. It is the last line
+// of an NDJSON stream or the last element of a streamed JSON array, so
+// clients detect truncated exports by looking for an "error" member. The
+// error member is rendered by the configured ErrorFormatter, so this type
+// describes the trailer written with the default RFC 9457 formatter.
+type StreamErrorRecord struct {
+	Error ProblemDetails `json:"error"`
+}
+
+// streamErrorTrailer is the trailer actually written, holding whatever
+// document the ErrorFormatter produced.
+type streamErrorTrailer struct {
+	Error any `json:"error"`
+}
+
+// WithFlushEvery flushes the response after every n records. The default is
+// 100; zero or less disables count based flushing.
+func WithFlushEvery(n int) StreamOption {
+	return func(cfg *streamConfig) {
+		cfg.flushEvery = n
+	}
+}
+
+// WithFlushInterval flushes the response once interval has passed since the
+// previous flush. The check runs between records, so a stalled sequence does
+// not trigger flushes. The default is one second; zero or less disables it.
+func WithFlushInterval(interval time.Duration) StreamOption {
+	return func(cfg *streamConfig) {
+		cfg.flushInterval = interval
+	}
+}
+
+// RespondStream writes the records produced by seq without buffering them
+// all in memory. Clients accepting application/x-ndjson (or
+// application/jsonl) receive one JSON document per line; everyone else gets a
+// streamed application/json array. An error yielded before the first record
+// is answered with a regular problem response. Later errors cannot change the
+// status code, so the stream ends with a StreamErrorRecord instead. Streaming
+// stops quietly when the client disconnects.
+func RespondStream[T any](r *Responder, w http.ResponseWriter, req *http.Request, seq iter.Seq2[T, error], opts ...StreamOption) {
+	if w == nil {
+		return
+	}
+
+	cfg := streamConfig{flushEvery: defaultStreamFlushEvery, flushInterval: defaultStreamFlushInterval}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	addVary(w.Header(), "Accept")
+	contentType, ok := negotiateStream(req)
+	if !ok {
+		r.HandleAPIError(w, req, http.StatusNotAcceptable,
+			fmt.Errorf("none of the available representations is acceptable: %s, %s", ndjsonContentType, jsonContentType),
+			"no acceptable representation")
+		return
+	}
+
+	next, stop := iter.Pull2(seq)
+	defer stop()
+
+	first, err, more := next()
+	if err != nil {
+		r.HandleErrors(w, req, err, "failed to produce stream")
+		return
+	}
+
+	stream := newRecordStream(w, contentType, cfg)
+	ctx := requestContext(req)
+	for item := first; more; item, err, more = next() {
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			problem, _ := r.problemFor(req, r.errorStatus(err), err, []string{"stream aborted"})
+			r.finishStream(stream, streamErrorTrailer{Error: r.formatterFor(req).Format(problem)})
+			return
+		}
+		if err := stream.record(item); err != nil {
+			r.logger().Error("failed to write stream record", "error", err)
+			return
+		}
+	}
+	r.finishStream(stream, nil)
+}
+
+func (r *Responder) finishStream(stream *recordStream, trailer any) {
+	if err := stream.close(trailer); err != nil {
+		r.logger().Error("failed to finish stream", "error", err)
+	}
+}
+
+// negotiateStream picks NDJSON when the client prefers it and falls back to a
+// JSON array, which is also the choice for requests without an Accept header.
+func negotiateStream(req *http.Request) (string, bool) {
+	accept := acceptHeader(req)
+	if accept == "" {
+		return jsonContentType, true
+	}
+
+	ranges := parseAccept(accept)
+	ndjsonQ, ndjsonSpecificity := matchMediaRanges(ndjsonContentType, ranges)
+	if jsonlQ, jsonlSpecificity := matchMediaRanges(jsonlContentType, ranges); jsonlQ > ndjsonQ {
+		ndjsonQ, ndjsonSpecificity = jsonlQ, jsonlSpecificity
+	}
+	jsonQ, jsonSpecificity := matchMediaRanges(jsonContentType, ranges)
+
+	switch {
+	case ndjsonQ == 0 && jsonQ == 0:
+		return "", false
+	case ndjsonQ > jsonQ || (ndjsonQ == jsonQ && ndjsonSpecificity > jsonSpecificity):
+		return ndjsonContentType, true
+	default:
+		return jsonContentType, true
+	}
+}
+
+// recordStream frames records as NDJSON lines or JSON array elements and
+// flushes according to the stream configuration.
+type recordStream struct {
+	controller *http.ResponseController
+	buf        *bufio.Writer
+	array      bool
+	cfg        streamConfig
+	records    int
+	pending    int
+	lastFlush  time.Time
+}
+
+func newRecordStream(w http.ResponseWriter, contentType string, cfg streamConfig) *recordStream {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	return &recordStream{
+		controller: http.NewResponseController(w),
+		buf:        bufio.NewWriter(w),
+		array:      contentType == jsonContentType,
+		cfg:        cfg,
+		lastFlush:  time.Now(),
+	}
+}
+
+func (s *recordStream) record(v any) error {
+	data, err := jsonutil.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := s.frame(data); err != nil {
+		return err
+	}
+
+	s.pending++
+	if (s.cfg.flushEvery > 0 && s.pending >= s.cfg.flushEvery) ||
+		(s.cfg.flushInterval > 0 && time.Since(s.lastFlush) >= s.cfg.flushInterval) {
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *recordStream) frame(data []byte) error {
+	separator := "\n"
+	if s.array {
+		separator = ","
+		if s.records == 0 {
+			separator = "["
+		}
+	}
+	s.records++
+
+	if s.array {
+		_, _ = s.buf.WriteString(separator)
+		_, err := s.buf.Write(data)
+		return err
+	}
+	_, _ = s.buf.Write(data)
+	_, err := s.buf.WriteString(separator)
+	return err
+}
+
+// close writes the optional trailer, terminates the array, and flushes.
+func (s *recordStream) close(trailer any) error {
+	if trailer != nil {
+		if err := s.record(trailer); err != nil {
+			return err
+		}
+	}
+	if s.array {
+		if s.records == 0 {
+			_, _ = s.buf.WriteString("[")
+		}
+		_, _ = s.buf.WriteString("]\n")
+	}
+	return s.flush()
+}
+
+func (s *recordStream) flush() error {
+	s.pending = 0
+	s.lastFlush = time.Now()
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+	if err := s.controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
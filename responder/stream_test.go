@@ -0,0 +1,54 @@
+package responder
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRespondStreamTrailerUsesErrorFormatter(t *testing.T) {
+	r := NewResponder(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithErrorFormatter(JSONAPIFormatter()),
+	)
+	failure := ServiceUnavailable(errors.New("replica lost")).WithDetail("export interrupted")
+	seq := iter.Seq2[int, error](func(yield func(int, error) bool) {
+		if yield(1, nil) {
+			yield(0, failure)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/exports", nil)
+	req.Header.Set("Accept", ndjsonContentType)
+	rec := httptest.NewRecorder()
+	RespondStream(r, rec, req, seq)
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a record and a trailer, got %q", rec.Body.String())
+	}
+	var trailer struct {
+		Error jsonAPIDocument `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &trailer); err != nil {
+		t.Fatalf("decode trailer: %v", err)
+	}
+
+	direct := httptest.NewRecorder()
+	r.HandleErrors(direct, httptest.NewRequest(http.MethodGet, "/exports", nil), failure)
+	var document jsonAPIDocument
+	if err := json.Unmarshal(direct.Body.Bytes(), &document); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+
+	if len(trailer.Error.Errors) != 1 || trailer.Error.Errors[0].Status != document.Errors[0].Status ||
+		trailer.Error.Errors[0].Detail != document.Errors[0].Detail {
+		t.Fatalf("trailer %+v does not match the error response %+v", trailer.Error, document)
+	}
+}
@@ -1,22 +1,227 @@
 package responder
 
 import (
-	mathrand "math/rand"
-	"sync"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/oklog/ulid/v2"
 )
 
-var (
-	entropyMu sync.Mutex
-	entropy   = ulid.Monotonic(mathrand.New(mathrand.NewSource(time.Now().UnixNano())), 0)
+const (
+	traceparentHeader   = "traceparent"
+	requestIDHeader     = "X-Request-ID"
+	maxIncomingTraceLen = 128
 )
 
-func newTraceID() string {
-	entropyMu.Lock()
-	defer entropyMu.Unlock()
+// TraceIDGenerator mints a trace identifier when none can be propagated from
+// the request. Generators are called concurrently and must be safe for that.
+type TraceIDGenerator func() string
 
-	id := ulid.MustNew(ulid.Timestamp(time.Now()), entropy)
-	return id.String()
+type traceIDContextKey struct{}
+
+// ContextWithTraceID returns a copy of ctx carrying the supplied trace
+// identifier. Middleware uses it so problem documents and logs reuse the ID.
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDContextKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace identifier stored by
+// ContextWithTraceID, if any.
+func TraceIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	traceID, ok := ctx.Value(traceIDContextKey{}).(string)
+	return traceID, ok && traceID != ""
+}
+
+// WithTraceIDHeaders registers request headers that carry a caller supplied
+// trace identifier. They are consulted in order before the W3C traceparent
+// and X-Request-ID headers.
+func WithTraceIDHeaders(headers ...string) ResponderOption {
+	return func(r *Responder) {
+		for _, header := range headers {
+			if header = strings.TrimSpace(header); header != "" {
+				r.traceHeaders = append(r.traceHeaders, header)
+			}
+		}
+	}
+}
+
+// WithTraceIDResponseHeader sets the response header used to echo the trace
+// identifier. The default is X-Request-ID; an empty name disables the echo.
+func WithTraceIDResponseHeader(header string) ResponderOption {
+	return func(r *Responder) {
+		r.traceResponseHeader = strings.TrimSpace(header)
+	}
+}
+
+// WithTraceIDGenerator replaces the generator used to mint trace identifiers.
+// Built-in choices are GenerateULID (the default), GenerateUUIDv4,
+// GenerateUUIDv7, and GenerateW3CTraceID.
+func WithTraceIDGenerator(generator TraceIDGenerator) ResponderOption {
+	return func(r *Responder) {
+		if generator != nil {
+			r.traceIDGenerator = generator
+		}
+	}
+}
+
+// GenerateULID returns a ULID drawing its entropy from crypto/rand. Unlike a
+// shared monotonic source it needs no lock, so concurrent error responses do
+// not serialise on ID generation.
+func GenerateULID() string {
+	return ulid.MustNew(ulid.Timestamp(time.Now()), rand.Reader).String()
+}
+
+// GenerateUUIDv4 returns a random RFC 9562 version 4 UUID.
+func GenerateUUIDv4() string {
+	var id [16]byte
+	fillRandom(id[:])
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return formatUUID(id)
+}
+
+// GenerateUUIDv7 returns an RFC 9562 version 7 UUID, which sorts by creation
+// time like a ULID while keeping the canonical UUID text form.
+func GenerateUUIDv7() string {
+	var id [16]byte
+	var millis [8]byte
+	binary.BigEndian.PutUint64(millis[:], uint64(time.Now().UnixMilli()))
+	copy(id[:6], millis[2:])
+	fillRandom(id[6:])
+	id[6] = (id[6] & 0x0f) | 0x70
+	id[8] = (id[8] & 0x3f) | 0x80
+	return formatUUID(id)
+}
+
+// GenerateW3CTraceID returns a random 16-byte trace-id in the lowercase hex
+// form used by the W3C traceparent header.
+func GenerateW3CTraceID() string {
+	var id [16]byte
+	for {
+		fillRandom(id[:])
+		if id != [16]byte{} {
+			return hex.EncodeToString(id[:])
+		}
+	}
+}
+
+// TraceID resolves the correlation identifier for the request. It prefers the
+// configured headers, then the trace-id of a W3C traceparent header, then
+// X-Request-ID, then an identifier stored in the request context, and only
+// mints a fresh one when none of those is present.
+func (r *Responder) TraceID(req *http.Request) string {
+	if req == nil {
+		return r.newTraceID()
+	}
+	if traceID, ok := r.incomingTraceID(req); ok {
+		return traceID
+	}
+	if traceID, ok := TraceIDFromContext(req.Context()); ok {
+		return traceID
+	}
+	return r.newTraceID()
+}
+
+// TraceIDMiddleware resolves the trace identifier once per request, stores it
+// in the request context, and echoes it in the configured response header so
+// every response, not only problem documents, can be correlated.
+func (r *Responder) TraceIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		traceID := r.TraceID(req)
+		r.echoTraceID(w, traceID)
+		next.ServeHTTP(w, req.WithContext(ContextWithTraceID(req.Context(), traceID)))
+	})
+}
+
+func (r *Responder) incomingTraceID(req *http.Request) (string, bool) {
+	for _, header := range r.traceHeaders {
+		if traceID, ok := sanitizeTraceID(req.Header.Get(header)); ok {
+			return traceID, true
+		}
+	}
+	if traceID, ok := parseTraceparent(req.Header.Get(traceparentHeader)); ok {
+		return traceID, true
+	}
+	return sanitizeTraceID(req.Header.Get(requestIDHeader))
+}
+
+func (r *Responder) echoTraceID(w http.ResponseWriter, traceID string) {
+	if w == nil || r.traceResponseHeader == "" || traceID == "" {
+		return
+	}
+	w.Header().Set(r.traceResponseHeader, traceID)
+}
+
+// parseTraceparent extracts the trace-id field from a W3C traceparent header
+// ("version-traceid-parentid-flags").
+func parseTraceparent(value string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", false
+	}
+	traceID := strings.ToLower(parts[1])
+	if len(traceID) != 32 || !isHex(traceID) || strings.Trim(traceID, "0") == "" {
+		return "", false
+	}
+	return traceID, true
+}
+
+// sanitizeTraceID accepts short, printable identifiers only, so caller
+// supplied values cannot inject content into logs or response headers.
+func sanitizeTraceID(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || len(value) > maxIncomingTraceLen {
+		return "", false
+	}
+	for _, ch := range value {
+		if ch < 0x21 || ch > 0x7e {
+			return "", false
+		}
+	}
+	return value, true
+}
+
+func isHex(value string) bool {
+	for _, ch := range value {
+		if (ch < '0' || ch > '9') && (ch < 'a' || ch > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Responder) newTraceID() string {
+	if r.traceIDGenerator != nil {
+		if traceID := r.traceIDGenerator(); traceID != "" {
+			return traceID
+		}
+	}
+	return GenerateULID()
+}
+
+func fillRandom(b []byte) {
+	// crypto/rand.Read never returns an error on supported platforms.
+	_, _ = rand.Read(b)
+}
+
+func formatUUID(id [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], id[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], id[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], id[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], id[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], id[10:])
+	return string(buf[:])
 }
@@ -0,0 +1,86 @@
+package responder
+
+import (
+	"strings"
+)
+
+// FieldError describes a single invalid input member. Pointer holds an RFC
+// 6901 JSON pointer into the request body, while Field names path, query, or
+// header parameters. Code is a stable, machine readable identifier such as
+// "required" or "too_long".
+type FieldError struct {
+	Pointer string `json:"pointer,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError aggregates field-level failures. HandleErrors answers it with
+// a 400 problem whose errors member lists every FieldError, and any other
+// problem built from an error chain containing it carries the same list unless
+// a RedactionPolicy hides it.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError constructs a ValidationError from the supplied failures.
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: append([]FieldError(nil), fields...)}
+}
+
+// Add appends a failure and returns the receiver to allow chaining.
+func (e *ValidationError) Add(field FieldError) *ValidationError {
+	e.Fields = append(e.Fields, field)
+	return e
+}
+
+// AddField records a failure for a named parameter.
+func (e *ValidationError) AddField(field, code, message string) *ValidationError {
+	return e.Add(FieldError{Field: field, Code: code, Message: message})
+}
+
+// AddPointer records a failure for a request body member addressed by a JSON
+// pointer.
+func (e *ValidationError) AddPointer(pointer, code, message string) *ValidationError {
+	return e.Add(FieldError{Pointer: pointer, Code: code, Message: message})
+}
+
+// Err returns the receiver when failures were recorded and nil otherwise, so
+// validators can build the error incrementally and return it unconditionally.
+func (e *ValidationError) Err() error {
+	if e == nil || len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	if e == nil || len(e.Fields) == 0 {
+		return "validation failed"
+	}
+
+	parts := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		parts = append(parts, field.String())
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// String renders the failure as "<location>: <message>".
+func (f FieldError) String() string {
+	location := f.Field
+	if location == "" {
+		location = f.Pointer
+	}
+	if location == "" {
+		return f.Message
+	}
+	return location + ": " + f.Message
+}
+
+func cloneFieldErrors(fields []FieldError) []FieldError {
+	if len(fields) == 0 {
+		return nil
+	}
+	return append([]FieldError(nil), fields...)
+}
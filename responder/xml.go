@@ -0,0 +1,65 @@
+package responder
+
+import (
+	"encoding/xml"
+	"sort"
+)
+
+// encodeXMLMember writes a problem member following RFC 9457 appendix B:
+// objects become nested elements, arrays repeat <i> elements, and scalars are
+// written as character data. Complex values are normalised through their JSON
+// representation first so both formats expose the same member names.
+func encodeXMLMember(e *xml.Encoder, name string, value any) error {
+	switch value.(type) {
+	case string, bool, int, int64, float64:
+		return e.EncodeElement(value, xmlStart(name))
+	}
+
+	normalized, err := normalizeJSON(value)
+	if err != nil {
+		return err
+	}
+	return encodeXMLValue(e, name, normalized)
+}
+
+func encodeXMLValue(e *xml.Encoder, name string, value any) error {
+	start := xmlStart(name)
+	switch typed := value.(type) {
+	case nil:
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		return e.EncodeToken(start.End())
+	case map[string]any:
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := encodeXMLValue(e, key, typed[key]); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	case []any:
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, item := range typed {
+			if err := encodeXMLValue(e, "i", item); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	default:
+		return e.EncodeElement(typed, start)
+	}
+}
+
+func xmlStart(name string) xml.StartElement {
+	return xml.StartElement{Name: xml.Name{Local: name}}
+}
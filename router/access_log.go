@@ -0,0 +1,181 @@
+package router
+
+import (
+	"bufio"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/getkin/kin-openapi/routers"
+
+	"github.com/drblury/apiweaver/responder"
+)
+
+// accessLogMiddleware writes a completion log line for every request. It runs
+// outermost in the default chain so that validation failures, CORS
+// preflights, and timeouts are logged with the status the client received.
+func accessLogMiddleware(logger *slog.Logger, quietdownRoutes []string, access accessLog) Middleware {
+	quietRoutesCopy := cloneStrings(quietdownRoutes)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = access.withTraceID(r)
+			recorder := &recordingWriter{ResponseWriter: w}
+			start := time.Now()
+			next.ServeHTTP(recorder, r)
+			access.log(logger, r, recorder, time.Since(start), shouldQuietRoute(r.URL.Path, quietRoutesCopy))
+		})
+	}
+}
+
+// accessLog writes the completion log line of the access log middleware.
+type accessLog struct {
+	cfg       AccessLogConfig
+	routes    func() routers.Router
+	responder *responder.Responder
+}
+
+// withTraceID stores the responder's trace identifier in the request context
+// when none is present yet, so the access log and any problem document
+// written by the handler report the same ID.
+func (a accessLog) withTraceID(r *http.Request) *http.Request {
+	if a.responder == nil {
+		return r
+	}
+	if _, ok := responder.TraceIDFromContext(r.Context()); ok {
+		return r
+	}
+	return r.WithContext(responder.ContextWithTraceID(r.Context(), a.responder.TraceID(r)))
+}
+
+func (a accessLog) log(logger *slog.Logger, r *http.Request, recorder *recordingWriter, elapsed time.Duration, quiet bool) {
+	status := recorder.statusCode()
+	slow := a.cfg.SlowThreshold > 0 && elapsed >= a.cfg.SlowThreshold
+	if status < http.StatusBadRequest && !slow && (quiet || !a.sampled()) {
+		return
+	}
+
+	level := a.level(status)
+	if slow {
+		level = max(level, slog.LevelWarn)
+	}
+	ctx := r.Context()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("Method", r.Method),
+		slog.String("Path", r.URL.Path),
+		slog.Int("Status", status),
+		slog.Duration("Duration", elapsed),
+		slog.Int64("BytesWritten", recorder.bytes),
+	}
+	if operationID := a.operationID(r); operationID != "" {
+		attrs = append(attrs, slog.String("OperationID", operationID))
+	}
+	if traceID, ok := responder.TraceIDFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("TraceID", traceID))
+	}
+	if slow {
+		attrs = append(attrs, slog.Bool("Slow", true))
+	}
+	logger.LogAttrs(ctx, level, "Request completed", attrs...)
+}
+
+func (a accessLog) level(status int) slog.Level {
+	if a.cfg.Level != nil {
+		return a.cfg.Level(status)
+	}
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func (a accessLog) sampled() bool {
+	rate := a.cfg.SuccessSampleRate
+	return rate <= 0 || rate >= 1 || rand.Float64() < rate
+}
+
+func (a accessLog) operationID(r *http.Request) string {
+	if a.routes == nil {
+		return ""
+	}
+	router := a.routes()
+	if router == nil {
+		return ""
+	}
+	route, _, err := router.FindRoute(r)
+	if err != nil || route.Operation == nil {
+		return ""
+	}
+	return route.Operation.OperationID
+}
+
+// recordingWriter captures the status code and body size of a response. It
+// keeps flushing and hijacking available to the handler by delegating to the
+// wrapped writer.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	// Informational responses other than 101 precede the final status.
+	if w.status == 0 && (status >= http.StatusOK || status == http.StatusSwitchingProtocols) {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// FlushError flushes the wrapped writer for http.ResponseController.
+func (w *recordingWriter) FlushError() error {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Flush implements http.Flusher.
+func (w *recordingWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// Hijack implements http.Hijacker. It fails with http.ErrNotSupported when
+// the wrapped writer cannot be hijacked.
+func (w *recordingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *recordingWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
@@ -0,0 +1,347 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrMissingCredentials reports a request that presents no credentials
+	// for a security scheme. It is answered with 401.
+	ErrMissingCredentials = errors.New("missing credentials")
+	// ErrInvalidCredentials reports credentials that could not be verified.
+	// It is answered with 401.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrForbidden reports an authenticated caller that may not perform the
+	// operation, for example because a required scope is missing. Wrap it to
+	// answer with 403 instead of 401.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Principal is the caller authenticated by a security scheme.
+type Principal struct {
+	// Subject identifies the caller, such as a user name or client id.
+	Subject string
+	// Scheme is the name of the security scheme that authenticated the caller.
+	Scheme string
+	// Scopes lists the permissions granted to the caller. Operations that
+	// require scopes are rejected with 403 unless all of them are present.
+	Scopes []string
+	// Metadata carries verifier specific details about the caller.
+	Metadata map[string]any
+	// Claims holds the verified token claims when the caller presented a JWT.
+	Claims *Claims
+}
+
+// Authenticator verifies the credentials a request presents for one OpenAPI
+// security scheme and returns the authenticated principal. Errors wrapping
+// ErrForbidden are answered with 403; every other error with 401. The
+// returned principal may be shared between requests; the router stores a copy
+// and never modifies it.
+type Authenticator func(ctx context.Context, input *openapi3filter.AuthenticationInput) (*Principal, error)
+
+// TokenVerifier resolves an API key or bearer token to its principal. It may
+// return a shared or cached principal, which the router does not modify.
+type TokenVerifier func(ctx context.Context, token string) (*Principal, error)
+
+// CredentialStore looks up the bcrypt hash of a user's password. It returns
+// an error when the user is unknown.
+type CredentialStore interface {
+	PasswordHash(ctx context.Context, username string) ([]byte, error)
+}
+
+// HashedCredentials is an in-memory CredentialStore mapping user names to
+// bcrypt password hashes.
+type HashedCredentials map[string]string
+
+// PasswordHash implements CredentialStore.
+func (c HashedCredentials) PasswordHash(_ context.Context, username string) ([]byte, error) {
+	hash, ok := c[username]
+	if !ok {
+		return nil, fmt.Errorf("unknown user %q", username)
+	}
+	return []byte(hash), nil
+}
+
+type principalContextKey struct{}
+
+type principalHolderKey struct{}
+
+// principalHolder collects the principals authenticated while the validator
+// checks a request; the AuthenticationFunc cannot replace the request context
+// itself. Principals are keyed by scheme and required scopes, because the same
+// scheme may appear with different scopes in several requirements.
+type principalHolder struct {
+	authenticated map[schemeAttempt]*Principal
+	requirements  openapi3.SecurityRequirements
+}
+
+type schemeAttempt struct {
+	scheme string
+	scopes string
+}
+
+func newSchemeAttempt(scheme string, scopes []string) schemeAttempt {
+	return schemeAttempt{scheme: scheme, scopes: strings.Join(scopes, " ")}
+}
+
+func (h *principalHolder) succeed(input *openapi3filter.AuthenticationInput, principal *Principal) {
+	if h.authenticated == nil {
+		h.authenticated = make(map[schemeAttempt]*Principal)
+	}
+	h.authenticated[newSchemeAttempt(input.SecuritySchemeName, input.Scopes)] = principal
+	h.requirements = securityRequirements(input.RequestValidationInput)
+}
+
+// accepted resolves the requirement that let the request through: like the
+// validator, the first one whose schemes all authenticated. It returns the
+// principal of that requirement's last scheme in name order, or nil when the
+// request passed through a requirement that authenticates nobody, such as an
+// anonymous alternative.
+func (h *principalHolder) accepted() *Principal {
+	for _, requirement := range h.requirements {
+		var principal *Principal
+		complete := true
+		for _, scheme := range slices.Sorted(maps.Keys(requirement)) {
+			authenticated, ok := h.authenticated[newSchemeAttempt(scheme, requirement[scheme])]
+			if !ok {
+				complete = false
+				break
+			}
+			principal = authenticated
+		}
+		if complete {
+			return principal
+		}
+	}
+	return nil
+}
+
+// PrincipalFromContext returns the principal authenticated for the request.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// ContextWithPrincipal returns a copy of ctx carrying principal, which is
+// useful when testing handlers without running the router.
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// APIKeyAuthenticator verifies API keys. The header, query parameter, or
+// cookie carrying the key is taken from the apiKey security scheme.
+func APIKeyAuthenticator(verify TokenVerifier) Authenticator {
+	return func(ctx context.Context, input *openapi3filter.AuthenticationInput) (*Principal, error) {
+		scheme := input.SecurityScheme
+		if scheme == nil || scheme.Type != "apiKey" {
+			return nil, fmt.Errorf("security scheme %q is not an apiKey scheme", input.SecuritySchemeName)
+		}
+
+		key := apiKeyFromRequest(input.RequestValidationInput.Request, scheme)
+		if key == "" {
+			return nil, fmt.Errorf("%w: API key %q not provided in %s", ErrMissingCredentials, scheme.Name, scheme.In)
+		}
+		return verify(ctx, key)
+	}
+}
+
+// BearerAuthenticator verifies the token of an "Authorization: Bearer"
+// header. It suits http bearer, oauth2, and openIdConnect schemes.
+func BearerAuthenticator(verify TokenVerifier) Authenticator {
+	return func(ctx context.Context, input *openapi3filter.AuthenticationInput) (*Principal, error) {
+		token, ok := authorizationCredentials(input.RequestValidationInput.Request, "Bearer")
+		if !ok || token == "" {
+			return nil, fmt.Errorf("%w: bearer token not provided", ErrMissingCredentials)
+		}
+		return verify(ctx, token)
+	}
+}
+
+// BasicAuthenticator verifies HTTP Basic credentials against bcrypt hashes
+// held by store. Unknown users take as long to reject as wrong passwords.
+func BasicAuthenticator(store CredentialStore) Authenticator {
+	return func(ctx context.Context, input *openapi3filter.AuthenticationInput) (*Principal, error) {
+		username, password, ok := input.RequestValidationInput.Request.BasicAuth()
+		if !ok {
+			return nil, fmt.Errorf("%w: basic credentials not provided", ErrMissingCredentials)
+		}
+
+		hash, err := store.PasswordHash(ctx, username)
+		if err != nil {
+			hash = unknownUserHash()
+		}
+		if compareErr := bcrypt.CompareHashAndPassword(hash, []byte(password)); compareErr != nil || err != nil {
+			return nil, ErrInvalidCredentials
+		}
+		return &Principal{Subject: username}, nil
+	}
+}
+
+// unknownUserHash is compared against when a user does not exist, so the
+// response time does not reveal which user names are valid.
+var unknownUserHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("apiweaver-unknown-user"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(fmt.Sprintf("router: cannot hash placeholder password: %v", err))
+	}
+	return hash
+})
+
+// authenticate dispatches security schemes to their authenticators. Without
+// any registered authenticator every requirement passes, as before
+// authenticators existed; otherwise schemes without one are rejected.
+func authenticate(authenticators map[string]Authenticator) openapi3filter.AuthenticationFunc {
+	return func(ctx context.Context, input *openapi3filter.AuthenticationInput) error {
+		if len(authenticators) == 0 {
+			return nil
+		}
+
+		principal, err := authenticateScheme(ctx, authenticators, input)
+		if holder, ok := ctx.Value(principalHolderKey{}).(*principalHolder); ok && err == nil {
+			holder.succeed(input, principal)
+		}
+		return err
+	}
+}
+
+func authenticateScheme(ctx context.Context, authenticators map[string]Authenticator, input *openapi3filter.AuthenticationInput) (*Principal, error) {
+	authenticator, ok := authenticators[input.SecuritySchemeName]
+	if !ok {
+		return nil, fmt.Errorf("no authenticator registered for security scheme %q", input.SecuritySchemeName)
+	}
+	principal, err := authenticator(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	// Copy the principal so verifiers can return shared or cached values.
+	var authenticated Principal
+	if principal != nil {
+		authenticated = *principal
+	}
+	authenticated.Scheme = input.SecuritySchemeName
+
+	if missing := missingScopes(input.Scopes, authenticated.Scopes); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing scopes %s", ErrForbidden, strings.Join(missing, ", "))
+	}
+	return &authenticated, nil
+}
+
+// securityRequirements returns the requirements the validator checks for
+// the matched operation: its own, or the document-wide default.
+func securityRequirements(input *openapi3filter.RequestValidationInput) openapi3.SecurityRequirements {
+	if input == nil || input.Route == nil {
+		return nil
+	}
+	if input.Route.Operation != nil && input.Route.Operation.Security != nil {
+		return *input.Route.Operation.Security
+	}
+	if input.Route.Spec != nil {
+		return input.Route.Spec.Security
+	}
+	return nil
+}
+
+// authenticationMiddleware installs the principal holder ahead of the
+// validator and hands the authenticated principal to the next handler.
+func authenticationMiddleware(validator func(http.Handler) http.Handler, next http.Handler) http.Handler {
+	inner := validator(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if holder, ok := ctx.Value(principalHolderKey{}).(*principalHolder); ok {
+			if principal := holder.accepted(); principal != nil {
+				ctx = context.WithValue(ctx, principalContextKey{}, principal)
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), principalHolderKey{}, &principalHolder{})
+		inner.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func missingScopes(required, granted []string) []string {
+	var missing []string
+	for _, scope := range required {
+		if !slices.Contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+func apiKeyFromRequest(r *http.Request, scheme *openapi3.SecurityScheme) string {
+	switch scheme.In {
+	case "header":
+		return r.Header.Get(scheme.Name)
+	case "query":
+		return r.URL.Query().Get(scheme.Name)
+	case "cookie":
+		if cookie, err := r.Cookie(scheme.Name); err == nil {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+func authorizationCredentials(r *http.Request, authScheme string) (string, bool) {
+	prefix, credentials, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(prefix, authScheme) {
+		return "", false
+	}
+	return strings.TrimSpace(credentials), true
+}
+
+// authFailureStatus answers with 403 when any alternative authenticated the
+// caller but denied access, and with 401 otherwise.
+func authFailureStatus(err error) int {
+	if errors.Is(err, ErrForbidden) {
+		return http.StatusForbidden
+	}
+	return http.StatusUnauthorized
+}
+
+// authChallenges builds the WWW-Authenticate challenges for the http schemes
+// named in the failed security requirements.
+func authChallenges(swagger *openapi3.T, secErr *openapi3filter.SecurityRequirementsError) []string {
+	if swagger == nil || swagger.Components == nil {
+		return nil
+	}
+
+	var challenges []string
+	for _, requirement := range secErr.SecurityRequirements {
+		for name := range requirement {
+			ref := swagger.Components.SecuritySchemes[name]
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			challenge := ""
+			switch scheme := ref.Value; {
+			case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+				challenge = `Basic realm="api"`
+			case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"),
+				scheme.Type == "oauth2", scheme.Type == "openIdConnect":
+				challenge = "Bearer"
+			}
+			if challenge != "" && !slices.Contains(challenges, challenge) {
+				challenges = append(challenges, challenge)
+			}
+		}
+	}
+	slices.Sort(challenges)
+	return challenges
+}
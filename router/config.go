@@ -1,6 +1,9 @@
 package router
 
-import "time"
+import (
+	"log/slog"
+	"time"
+)
 
 // Config configures the router.
 type Config struct {
@@ -8,6 +11,7 @@ type Config struct {
 	CORS            CORSConfig
 	QuietdownRoutes []string
 	HideHeaders     []string
+	AccessLog       AccessLogConfig
 }
 
 // CORSConfig configures CORS.
@@ -17,3 +21,18 @@ type CORSConfig struct {
 	Methods          []string
 	Origins          []string
 }
+
+// AccessLogConfig configures the completion log the logging middleware writes
+// after each handler returns.
+type AccessLogConfig struct {
+	// Level picks the log level for a status code. By default 5xx responses
+	// are logged at Error, 4xx at Warn, and everything else at Info.
+	Level func(status int) slog.Level
+	// SuccessSampleRate is the fraction of responses below 400 that are
+	// logged, from 0 to 1. Zero logs all of them. Errors and slow requests
+	// are always logged.
+	SuccessSampleRate float64
+	// SlowThreshold logs requests taking at least this long at Warn or above,
+	// marked as slow, regardless of sampling. Zero disables the check.
+	SlowThreshold time.Duration
+}
@@ -0,0 +1,258 @@
+package router
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/drblury/apiweaver/jsonutil"
+)
+
+const (
+	minRSAKeyBits  = 2048
+	minHMACKeySize = 32
+)
+
+// JWKS is a JSON Web Key Set holding the keys that verify JWT signatures.
+// Sets loaded with LoadJWKS or LoadJWKSFile can reload themselves; lookups
+// stay safe while a reload swaps the keys.
+type JWKS struct {
+	mu       sync.RWMutex
+	keys     []jsonWebKey
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// JWKSOption tunes LoadJWKS and LoadJWKSFile.
+type JWKSOption func(*jwksConfig)
+
+type jwksConfig struct {
+	reload time.Duration
+	logger *slog.Logger
+}
+
+type jsonWebKey struct {
+	kid string
+	alg string
+	key any
+}
+
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// WithJWKSReload re-reads the key set every interval so rotated keys are
+// picked up without a restart. A reload that fails keeps the previous keys
+// and is logged.
+func WithJWKSReload(interval time.Duration) JWKSOption {
+	return func(cfg *jwksConfig) {
+		cfg.reload = interval
+	}
+}
+
+// WithJWKSLogger sets the logger that reports failed reloads. It defaults to
+// slog.Default().
+func WithJWKSLogger(logger *slog.Logger) JWKSOption {
+	return func(cfg *jwksConfig) {
+		if logger != nil {
+			cfg.logger = logger
+		}
+	}
+}
+
+// ParseJWKS parses a JSON Web Key Set document. RSA, P-256 EC, Ed25519 OKP,
+// and symmetric oct keys are supported; keys of other types or meant for
+// encryption are skipped.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	return &JWKS{keys: keys}, nil
+}
+
+// LoadJWKS reads the key set stored as name in fsys.
+func LoadJWKS(fsys fs.FS, name string, opts ...JWKSOption) (*JWKS, error) {
+	cfg := jwksConfig{logger: slog.Default()}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	load := func() ([]jsonWebKey, error) {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		return parseJWKS(data)
+	}
+	keys, err := load()
+	if err != nil {
+		return nil, fmt.Errorf("load JWKS %s: %w", name, err)
+	}
+
+	set := &JWKS{keys: keys, stop: make(chan struct{})}
+	if cfg.reload > 0 {
+		go set.reloadEvery(cfg.reload, load, cfg.logger.With("jwks", name))
+	}
+	return set, nil
+}
+
+// LoadJWKSFile reads the key set from the file at path.
+func LoadJWKSFile(path string, opts ...JWKSOption) (*JWKS, error) {
+	return LoadJWKS(os.DirFS(filepath.Dir(path)), filepath.Base(path), opts...)
+}
+
+// Close stops the periodic reload. The keys loaded so far remain usable.
+func (s *JWKS) Close() {
+	if s.stop == nil {
+		return
+	}
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+func (s *JWKS) reloadEvery(interval time.Duration, load func() ([]jsonWebKey, error), logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			keys, err := load()
+			if err != nil {
+				logger.Warn("failed to reload JWKS, keeping previous keys", "error", err)
+				continue
+			}
+			s.mu.Lock()
+			s.keys = keys
+			s.mu.Unlock()
+		}
+	}
+}
+
+// verify reports whether any key matching kid and alg produced sig over
+// signed. Tokens without a kid are checked against every key.
+func (s *JWKS) verify(kid, alg string, signed, sig []byte) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	keys := s.keys
+	s.mu.RUnlock()
+
+	for _, key := range keys {
+		if kid != "" && key.kid != kid {
+			continue
+		}
+		if key.alg != "" && key.alg != alg {
+			continue
+		}
+		if verifySignature(alg, key.key, signed, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseJWKS(data []byte) ([]jsonWebKey, error) {
+	var doc struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := jsonutil.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+
+	keys := make([]jsonWebKey, 0, len(doc.Keys))
+	for idx, raw := range doc.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := raw.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %d (%q): %w", idx, raw.Kid, err)
+		}
+		if key != nil {
+			keys = append(keys, jsonWebKey{kid: raw.Kid, alg: raw.Alg, key: key})
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+// publicKey decodes the verification key. Unsupported key types yield nil.
+func (k rawJWK) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		return k.rsaKey()
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, nil
+		}
+		x, errX := decodeSegment(k.X)
+		y, errY := decodeSegment(k.Y)
+		if err := errors.Join(errX, errY); err != nil || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid P-256 coordinates")
+		}
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{4}, x...), y...))
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := decodeSegment(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		secret, err := decodeSegment(k.K)
+		if err != nil || len(secret) < minHMACKeySize {
+			return nil, fmt.Errorf("symmetric keys need at least %d bytes", minHMACKeySize)
+		}
+		return secret, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (k rawJWK) rsaKey() (*rsa.PublicKey, error) {
+	n, errN := decodeSegment(k.N)
+	e, errE := decodeSegment(k.E)
+	if err := errors.Join(errN, errE); err != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid RSA modulus or exponent")
+	}
+
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	if key.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA keys need at least %d bits", minRSAKeyBits)
+	}
+	return key, nil
+}
+
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(segment)
+}
@@ -0,0 +1,303 @@
+package router
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/drblury/apiweaver/jsonutil"
+)
+
+const defaultScopeClaim = "scope"
+
+// supportedJWTAlgorithms lists the JWS algorithms JWTAuthenticator accepts
+// unless WithJWTAlgorithms narrows them.
+var supportedJWTAlgorithms = []string{"HS256", "RS256", "ES256", "EdDSA"}
+
+// Claims are the verified claims of a JWT bearer token. The registered claims
+// are parsed; Decode exposes the full payload, including private claims.
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	ID        string
+	Scopes    []string
+
+	raw []byte
+}
+
+// Decode unmarshals the complete claim set into v.
+func (c *Claims) Decode(v any) error {
+	return jsonutil.Unmarshal(c.raw, v)
+}
+
+// ClaimsFromContext returns the JWT claims of the authenticated principal.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.Claims == nil {
+		return nil, false
+	}
+	return principal.Claims, true
+}
+
+// ClaimsAs decodes the JWT claims of the authenticated principal into T, so
+// handlers can read private claims through their own struct.
+func ClaimsAs[T any](ctx context.Context) (T, bool) {
+	var out T
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return out, false
+	}
+	if err := claims.Decode(&out); err != nil {
+		return out, false
+	}
+	return out, true
+}
+
+// JWTOption tunes JWTAuthenticator.
+type JWTOption func(*jwtConfig)
+
+type jwtConfig struct {
+	issuer     string
+	audience   []string
+	skew       time.Duration
+	scopeClaim string
+	algorithms []string
+}
+
+// WithJWTIssuer requires the iss claim to equal issuer.
+func WithJWTIssuer(issuer string) JWTOption {
+	return func(cfg *jwtConfig) {
+		cfg.issuer = issuer
+	}
+}
+
+// WithJWTAudience requires the aud claim to name at least one of audience.
+func WithJWTAudience(audience ...string) JWTOption {
+	return func(cfg *jwtConfig) {
+		cfg.audience = append([]string(nil), audience...)
+	}
+}
+
+// WithJWTClockSkew tolerates clocks drifting by up to skew when checking exp
+// and nbf. No skew is tolerated by default.
+func WithJWTClockSkew(skew time.Duration) JWTOption {
+	return func(cfg *jwtConfig) {
+		cfg.skew = max(skew, 0)
+	}
+}
+
+// WithJWTScopeClaim reads the granted scopes from claim instead of "scope".
+// The claim may hold a space separated string or an array of strings.
+func WithJWTScopeClaim(claim string) JWTOption {
+	return func(cfg *jwtConfig) {
+		if claim != "" {
+			cfg.scopeClaim = claim
+		}
+	}
+}
+
+// WithJWTAlgorithms restricts the accepted signing algorithms to a subset of
+// HS256, RS256, ES256, and EdDSA.
+func WithJWTAlgorithms(algorithms ...string) JWTOption {
+	return func(cfg *jwtConfig) {
+		cfg.algorithms = slices.DeleteFunc(slices.Clone(algorithms), func(alg string) bool {
+			return !slices.Contains(supportedJWTAlgorithms, alg)
+		})
+	}
+}
+
+// JWTAuthenticator verifies JWT bearer tokens signed by a key in keys. Tokens
+// must carry an exp claim; nbf, iss, and aud are checked as configured. The
+// scope claim becomes Principal.Scopes, so the scopes listed in the spec's
+// security requirements are enforced, and the claims are available through
+// ClaimsFromContext and ClaimsAs.
+func JWTAuthenticator(keys *JWKS, opts ...JWTOption) Authenticator {
+	cfg := jwtConfig{scopeClaim: defaultScopeClaim, algorithms: supportedJWTAlgorithms}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	return BearerAuthenticator(func(_ context.Context, token string) (*Principal, error) {
+		claims, err := cfg.verify(keys, token, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+		}
+		return &Principal{Subject: claims.Subject, Scopes: claims.Scopes, Claims: claims}, nil
+	})
+}
+
+func (cfg jwtConfig) verify(keys *JWKS, token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	headerJSON, err := decodeSegment(parts[0])
+	if err != nil || jsonutil.Unmarshal(headerJSON, &header) != nil {
+		return nil, errors.New("malformed token header")
+	}
+	if !slices.Contains(cfg.algorithms, header.Alg) {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", header.Alg)
+	}
+
+	sig, err := decodeSegment(parts[2])
+	if err != nil || !keys.verify(header.Kid, header.Alg, []byte(parts[0]+"."+parts[1]), sig) {
+		return nil, errors.New("invalid token signature")
+	}
+
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed token payload")
+	}
+	claims, err := parseClaims(payload, cfg.scopeClaim)
+	if err != nil {
+		return nil, err
+	}
+	return claims, cfg.validate(claims, now)
+}
+
+func (cfg jwtConfig) validate(claims *Claims, now time.Time) error {
+	switch {
+	case claims.ExpiresAt.IsZero():
+		return errors.New("token has no expiry")
+	case now.After(claims.ExpiresAt.Add(cfg.skew)):
+		return errors.New("token expired")
+	case !claims.NotBefore.IsZero() && now.Add(cfg.skew).Before(claims.NotBefore):
+		return errors.New("token not valid yet")
+	case cfg.issuer != "" && claims.Issuer != cfg.issuer:
+		return errors.New("unexpected token issuer")
+	case len(cfg.audience) > 0 && !slices.ContainsFunc(claims.Audience, func(aud string) bool {
+		return slices.Contains(cfg.audience, aud)
+	}):
+		return errors.New("token not issued for this audience")
+	default:
+		return nil
+	}
+}
+
+func parseClaims(payload []byte, scopeClaim string) (*Claims, error) {
+	var values map[string]any
+	if err := jsonutil.Unmarshal(payload, &values); err != nil {
+		return nil, errors.New("malformed token claims")
+	}
+
+	claims := &Claims{
+		Issuer:   claimString(values, "iss"),
+		Subject:  claimString(values, "sub"),
+		ID:       claimString(values, "jti"),
+		Audience: claimStrings(values["aud"]),
+		Scopes:   claimScopes(values[scopeClaim]),
+		raw:      payload,
+	}
+	var err error
+	if claims.ExpiresAt, err = claimTime(values, "exp"); err != nil {
+		return nil, err
+	}
+	if claims.NotBefore, err = claimTime(values, "nbf"); err != nil {
+		return nil, err
+	}
+	if claims.IssuedAt, err = claimTime(values, "iat"); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func claimString(values map[string]any, name string) string {
+	value, _ := values[name].(string)
+	return value
+}
+
+// claimStrings accepts a single string or an array of strings. A single
+// string is one value even if it contains spaces, as RFC 7519 requires for
+// aud.
+func claimStrings(value any) []string {
+	switch typed := value.(type) {
+	case string:
+		if typed == "" {
+			return nil
+		}
+		return []string{typed}
+	case []any:
+		out := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// claimScopes accepts a space separated scope string, as in RFC 8693, or an
+// array of scopes.
+func claimScopes(value any) []string {
+	if scopes, ok := value.(string); ok {
+		return strings.Fields(scopes)
+	}
+	return claimStrings(value)
+}
+
+func claimTime(values map[string]any, name string) (time.Time, error) {
+	value, ok := values[name]
+	if !ok {
+		return time.Time{}, nil
+	}
+	seconds, ok := value.(float64)
+	if !ok || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return time.Time{}, fmt.Errorf("claim %q is not a numeric date", name)
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second))), nil
+}
+
+func verifySignature(alg string, key any, signed, sig []byte) bool {
+	digest := sha256.Sum256(signed)
+	switch alg {
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok {
+			return false
+		}
+		mac := hmac.New(sha256.New, secret)
+		_, _ = mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), sig)
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return false
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, digest[:], r, s)
+	case "EdDSA":
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, signed, sig)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,289 @@
+package router
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/drblury/apiweaver/responder"
+)
+
+type jwtTestKeys struct {
+	hmac  []byte
+	rsa   *rsa.PrivateKey
+	ec    *ecdsa.PrivateKey
+	ed    ed25519.PrivateKey
+	jwks  []byte
+	names map[string]string
+}
+
+func TestJWTAuthenticatorVerifiesSupportedAlgorithms(t *testing.T) {
+	keys := newJWTTestKeys(t)
+	mux := newJWTTestRouter(t, keys, WithJWTIssuer("https://issuer.example"), WithJWTAudience("reports-api"))
+
+	for _, alg := range supportedJWTAlgorithms {
+		t.Run(alg, func(t *testing.T) {
+			token := keys.sign(t, alg, map[string]any{
+				"iss":    "https://issuer.example",
+				"aud":    []string{"reports-api"},
+				"sub":    "user-" + alg,
+				"exp":    time.Now().Add(time.Minute).Unix(),
+				"scope":  "reports:read profile",
+				"tenant": "acme",
+			})
+
+			rr := serveWithToken(mux, token)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("unexpected status code: got %d want %d (body %s)", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			if got, want := rr.Body.String(), "user-"+alg+"@acme"; got != want {
+				t.Fatalf("unexpected claims: got %q want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticatorRejectsInvalidTokens(t *testing.T) {
+	keys := newJWTTestKeys(t)
+	mux := newJWTTestRouter(t, keys,
+		WithJWTIssuer("https://issuer.example"),
+		WithJWTAudience("reports-api"),
+		WithJWTClockSkew(30*time.Second),
+	)
+
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss":   "https://issuer.example",
+			"aud":   "reports-api",
+			"sub":   "ada",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"scope": "reports:read",
+		}
+	}
+	with := func(key string, value any) map[string]any {
+		claims := valid()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	cases := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{name: "within skew", token: keys.sign(t, "ES256", with("exp", time.Now().Add(-10*time.Second).Unix())), status: http.StatusOK},
+		{name: "expired", token: keys.sign(t, "ES256", with("exp", time.Now().Add(-time.Minute).Unix())), status: http.StatusUnauthorized},
+		{name: "no expiry", token: keys.sign(t, "ES256", with("exp", nil)), status: http.StatusUnauthorized},
+		{name: "not yet valid", token: keys.sign(t, "ES256", with("nbf", time.Now().Add(time.Minute).Unix())), status: http.StatusUnauthorized},
+		{name: "wrong issuer", token: keys.sign(t, "ES256", with("iss", "https://evil.example")), status: http.StatusUnauthorized},
+		{name: "wrong audience", token: keys.sign(t, "ES256", with("aud", "billing-api")), status: http.StatusUnauthorized},
+		{name: "audience with spaces", token: keys.sign(t, "ES256", with("aud", "reports-api billing-api")), status: http.StatusUnauthorized},
+		{name: "scope array", token: keys.sign(t, "ES256", with("scope", []string{"profile", "reports:read"})), status: http.StatusOK},
+		{name: "missing scope", token: keys.sign(t, "ES256", with("scope", "profile")), status: http.StatusForbidden},
+		{name: "tampered", token: tamper(keys.sign(t, "ES256", valid())), status: http.StatusUnauthorized},
+		{name: "none algorithm", token: unsignedToken(valid()), status: http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := serveWithToken(mux, tc.token)
+			if rr.Code != tc.status {
+				t.Fatalf("unexpected status code: got %d want %d (body %s)", rr.Code, tc.status, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestLoadJWKSFileReloadsRotatedKeys(t *testing.T) {
+	oldKeys := newJWTTestKeys(t)
+	newKeys := newJWTTestKeys(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, oldKeys.jwks, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+
+	set, err := LoadJWKSFile(path, WithJWKSReload(5*time.Millisecond), WithJWKSLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("load jwks: %v", err)
+	}
+	t.Cleanup(set.Close)
+
+	cfg := jwtConfig{scopeClaim: defaultScopeClaim, algorithms: supportedJWTAlgorithms}
+	claims := map[string]any{"sub": "ada", "exp": time.Now().Add(time.Minute).Unix()}
+	if _, err := cfg.verify(set, newKeys.sign(t, "EdDSA", claims), time.Now()); err == nil {
+		t.Fatal("expected token signed by an unknown key to be rejected")
+	}
+
+	if err := os.WriteFile(path, newKeys.jwks, 0o600); err != nil {
+		t.Fatalf("rotate jwks: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := cfg.verify(set, newKeys.sign(t, "EdDSA", claims), time.Now()); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("rotated key was not picked up by the reload")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newJWTTestRouter(t *testing.T, keys *jwtTestKeys, opts ...JWTOption) *http.ServeMux {
+	t.Helper()
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.3
+info:
+  title: jwt
+  version: "1"
+components:
+  securitySchemes:
+    bearer:
+      type: http
+      scheme: bearer
+      bearerFormat: JWT
+paths:
+  /reports:
+    get:
+      security:
+        - bearer: [reports:read]
+      responses:
+        "200":
+          description: ok
+`))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	set, err := ParseJWKS(keys.jwks)
+	if err != nil {
+		t.Fatalf("parse jwks: %v", err)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		type tenantClaims struct {
+			Subject string `json:"sub"`
+			Tenant  string `json:"tenant"`
+		}
+		claims, ok := ClaimsAs[tenantClaims](r.Context())
+		if !ok {
+			t.Error("expected typed claims in the request context")
+			return
+		}
+		_, _ = w.Write([]byte(claims.Subject + "@" + claims.Tenant))
+	})
+
+	return New(
+		handler,
+		WithSwagger(swagger),
+		WithResponder(responder.NewResponder(responder.WithLogger(slog.New(slog.DiscardHandler)))),
+		WithAuthenticator("bearer", JWTAuthenticator(set, opts...)),
+		WithoutLoggingMiddleware(),
+	)
+}
+
+func serveWithToken(mux http.Handler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/reports", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	return rr
+}
+
+func newJWTTestKeys(t *testing.T) *jwtTestKeys {
+	t.Helper()
+
+	keys := &jwtTestKeys{hmac: make([]byte, 32)}
+	_, _ = rand.Read(keys.hmac)
+	var err error
+	if keys.rsa, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	if keys.ec, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		t.Fatalf("generate ec key: %v", err)
+	}
+	if _, keys.ed, err = ed25519.GenerateKey(rand.Reader); err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+
+	ecPoint, err := keys.ec.PublicKey.Bytes()
+	if err != nil {
+		t.Fatalf("encode ec key: %v", err)
+	}
+	enc := base64.RawURLEncoding.EncodeToString
+	keys.names = map[string]string{"HS256": "hs", "RS256": "rs", "ES256": "es", "EdDSA": "ed"}
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "oct", "kid": "hs", "alg": "HS256", "k": enc(keys.hmac)},
+		{"kty": "RSA", "kid": "rs", "n": enc(keys.rsa.N.Bytes()), "e": "AQAB"},
+		{"kty": "EC", "kid": "es", "crv": "P-256", "x": enc(ecPoint[1:33]), "y": enc(ecPoint[33:])},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": enc(keys.ed.Public().(ed25519.PublicKey))},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}})
+	if err != nil {
+		t.Fatalf("encode jwks: %v", err)
+	}
+	keys.jwks = jwks
+	return keys
+}
+
+func (k *jwtTestKeys) sign(t *testing.T, alg string, claims map[string]any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": k.names[alg]})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var sig []byte
+	var err error
+	switch alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, k.hmac)
+		mac.Write([]byte(signingInput))
+		sig = mac.Sum(nil)
+	case "RS256":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+	case "ES256":
+		r, s, signErr := ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		sig, err = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...), signErr
+	case "EdDSA":
+		sig = ed25519.Sign(k.ed, []byte(signingInput))
+	}
+	if err != nil {
+		t.Fatalf("sign %s token: %v", alg, err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func tamper(token string) string {
+	parts := strings.Split(token, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(payload), "ada", "eve", 1)))
+	return strings.Join(parts, ".")
+}
+
+func unsignedToken(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "none"})
+	payload, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+}
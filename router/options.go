@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+
+	"github.com/drblury/apiweaver/responder"
 )
 
 // Middleware wraps an http.Handler to produce a new http.Handler.
@@ -18,6 +21,9 @@ type options struct {
 	config        Config
 	logger        *slog.Logger
 	swagger       *openapi3.T
+	responder     *responder.Responder
+	auth          map[string]Authenticator
+	validateResp  *ResponseValidationConfig
 	prepend       []Middleware
 	append        []Middleware
 	override      []Middleware
@@ -55,10 +61,21 @@ func (o *options) middlewareChain() []Middleware {
 }
 
 func (o *options) defaultMiddlewares() []Middleware {
-	chain := make([]Middleware, 0, 4)
+	chain := make([]Middleware, 0, 6)
+
+	// The middlewares that look up the matched operation share one router,
+	// built on the first request that needs it.
+	var routes func() routers.Router
+	if o.swagger != nil {
+		routes = lazyRouter(o.swagger, o.logger)
+	}
+
+	if o.enableLogging && o.logger != nil {
+		chain = append(chain, accessLogMiddleware(o.logger, o.config.QuietdownRoutes, o.accessLog(routes)))
+	}
 
 	if o.enableOpenAPI && o.swagger != nil {
-		chain = append(chain, oapiMiddleware(o.swagger))
+		chain = append(chain, oapiMiddleware(o.swagger, o.responder, o.auth, routes))
 	}
 
 	if o.enableCORS && shouldApplyCORS(o.config.CORS) {
@@ -66,16 +83,30 @@ func (o *options) defaultMiddlewares() []Middleware {
 	}
 
 	if o.enableTimeout && o.config.Timeout > 0 {
-		chain = append(chain, timeoutMiddleware(o.config.Timeout))
+		chain = append(chain, timeoutMiddleware(o.config.Timeout, o.responder))
 	}
 
 	if o.enableLogging && o.logger != nil {
 		chain = append(chain, loggingMiddleware(o.logger, o.config.QuietdownRoutes, o.config.HideHeaders))
 	}
 
+	if o.validateResp != nil && o.swagger != nil {
+		chain = append(chain, responseValidationMiddleware(routes, *o.validateResp, o.logger, o.responder))
+	}
+
 	return chain
 }
 
+// validatesRequests reports whether the default chain validates requests
+// against the swagger document, which is where authenticators run.
+func (o *options) validatesRequests() bool {
+	return len(o.override) == 0 && o.enableOpenAPI && o.swagger != nil
+}
+
+func (o *options) accessLog(routes func() routers.Router) accessLog {
+	return accessLog{cfg: o.config.AccessLog, routes: routes, responder: o.responder}
+}
+
 // WithConfig replaces the router configuration with the provided value.
 func WithConfig(cfg Config) Option {
 	configCopy := sanitizeConfig(cfg)
@@ -107,6 +138,46 @@ func WithSwagger(swagger *openapi3.T) Option {
 	}
 }
 
+// WithResponder renders OpenAPI validation failures, route misses, and
+// timeouts as problem details through the responder. Validation failures list
+// every offending parameter or body member as a field error.
+func WithResponder(res *responder.Responder) Option {
+	return func(o *options) {
+		o.responder = res
+	}
+}
+
+// WithAuthenticator verifies the OpenAPI security scheme named scheme with
+// authenticator. Once any authenticator is registered, operations whose
+// security requirements cannot be satisfied are rejected, and the principal of
+// accepted requests is available through PrincipalFromContext. Combine it with
+// WithResponder to answer failures with 401 or 403 problem details.
+// Authenticators run inside the OpenAPI validation middleware, so New panics
+// when they are combined with WithoutOpenAPIValidation, WithMiddlewareChain,
+// or a missing WithSwagger document.
+func WithAuthenticator(scheme string, authenticator Authenticator) Option {
+	return func(o *options) {
+		if authenticator == nil {
+			return
+		}
+		if o.auth == nil {
+			o.auth = make(map[string]Authenticator)
+		}
+		o.auth[scheme] = authenticator
+	}
+}
+
+// WithResponseValidation validates handler responses against the operation's
+// declared responses in the swagger document, innermost in the default chain.
+// Violations are logged; ResponseValidationFail also replaces the response
+// with a 500 error, rendered through the responder when one is configured.
+func WithResponseValidation(cfg ResponseValidationConfig) Option {
+	return func(o *options) {
+		cfg.SampleRate = min(max(cfg.SampleRate, 0), 1)
+		o.validateResp = &cfg
+	}
+}
+
 // WithMiddlewares prepends custom middlewares ahead of the default chain.
 func WithMiddlewares(middlewares ...Middleware) Option {
 	return func(o *options) {
@@ -0,0 +1,210 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	oapiMW "github.com/oapi-codegen/nethttp-middleware"
+
+	"github.com/drblury/apiweaver/responder"
+)
+
+// probedMethods are the methods tried when building the Allow header of a
+// 405 response.
+var probedMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// problemErrorHandler renders errors reported by the OpenAPI validation
+// middleware as problem details. Route misses become 404 or 405 problems;
+// validation failures keep the status suggested by the middleware and list
+// every offending parameter or body member.
+func problemErrorHandler(res *responder.Responder, routes func() routers.Router, swagger *openapi3.T) oapiMW.ErrorHandlerWithOpts {
+	return func(_ context.Context, err error, w http.ResponseWriter, r *http.Request, opts oapiMW.ErrorHandlerOpts) {
+		if opts.MatchedRoute == nil {
+			handleRouteMiss(res, routes(), w, r, err)
+			return
+		}
+		if secErr := securityError(err); secErr != nil {
+			handleAuthFailure(res, swagger, w, r, secErr)
+			return
+		}
+
+		fields := requestFieldErrors(err)
+		if len(fields) == 0 {
+			res.HandleAPIError(w, r, opts.StatusCode, err, "request rejected by OpenAPI validation")
+			return
+		}
+
+		invalid := responder.NewStatusError(opts.StatusCode, responder.NewValidationError(fields...)).
+			WithDetail("The request does not match the API specification.")
+		res.HandleAPIError(w, r, opts.StatusCode, invalid, "request rejected by OpenAPI validation")
+	}
+}
+
+func handleRouteMiss(res *responder.Responder, router routers.Router, w http.ResponseWriter, r *http.Request, err error) {
+	if !errors.Is(err, routers.ErrMethodNotAllowed) {
+		res.HandleAPIError(w, r, http.StatusNotFound, err, "no matching operation")
+		return
+	}
+
+	if allowed := allowedMethods(router, r); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+	res.HandleAPIError(w, r, http.StatusMethodNotAllowed, err, "method not allowed")
+}
+
+// handleAuthFailure answers failed security requirements before any field
+// errors, so unauthenticated callers learn nothing about the request shape.
+func handleAuthFailure(res *responder.Responder, swagger *openapi3.T, w http.ResponseWriter, r *http.Request, secErr *openapi3filter.SecurityRequirementsError) {
+	status := authFailureStatus(secErr)
+	if status == http.StatusUnauthorized {
+		if challenges := authChallenges(swagger, secErr); len(challenges) > 0 {
+			w.Header().Set("WWW-Authenticate", strings.Join(challenges, ", "))
+		}
+	}
+	res.HandleAPIError(w, r, status, secErr, "request failed authentication")
+}
+
+func securityError(err error) *openapi3filter.SecurityRequirementsError {
+	switch e := err.(type) {
+	case *openapi3filter.SecurityRequirementsError:
+		return e
+	case openapi3.MultiError:
+		for _, inner := range e {
+			if secErr := securityError(inner); secErr != nil {
+				return secErr
+			}
+		}
+	}
+	return nil
+}
+
+// allowedMethods probes the router with every common method to find the
+// operations declared for the request path.
+func allowedMethods(router routers.Router, r *http.Request) []string {
+	if router == nil {
+		return nil
+	}
+
+	var allowed []string
+	for _, method := range probedMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, _, err := router.FindRoute(probe); err == nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// requestFieldErrors flattens the errors returned by openapi3filter into
+// field-level failures: parameters are reported by name, body members by
+// their JSON pointer, and schema violations by the failing schema keyword.
+func requestFieldErrors(err error) []responder.FieldError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var fields []responder.FieldError
+		for _, inner := range e {
+			fields = append(fields, requestFieldErrors(inner)...)
+		}
+		return fields
+	case *openapi3filter.RequestError:
+		return requestErrorFields(e)
+	default:
+		return nil
+	}
+}
+
+func requestErrorFields(reqErr *openapi3filter.RequestError) []responder.FieldError {
+	name := ""
+	if reqErr.Parameter != nil {
+		name = reqErr.Parameter.Name
+	}
+	isBody := reqErr.Parameter == nil && reqErr.RequestBody != nil
+
+	schemaErrs := schemaErrors(reqErr.Err)
+	if len(schemaErrs) == 0 {
+		return []responder.FieldError{{Field: name, Code: requestErrorCode(reqErr.Err), Message: requestErrorMessage(reqErr)}}
+	}
+
+	fields := make([]responder.FieldError, 0, len(schemaErrs))
+	for _, schemaErr := range schemaErrs {
+		field := responder.FieldError{Field: name, Code: schemaErr.SchemaField, Message: schemaErr.Reason}
+		if isBody {
+			field.Pointer = jsonPointer(schemaErr.JSONPointer())
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func schemaErrors(err error) []*openapi3.SchemaError {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *openapi3.SchemaError:
+		return []*openapi3.SchemaError{e}
+	case openapi3.MultiError:
+		var out []*openapi3.SchemaError
+		for _, inner := range e {
+			out = append(out, schemaErrors(inner)...)
+		}
+		return out
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		return []*openapi3.SchemaError{schemaErr}
+	}
+	return nil
+}
+
+func requestErrorCode(err error) string {
+	var parseErr *openapi3filter.ParseError
+	switch {
+	case errors.Is(err, openapi3filter.ErrInvalidRequired):
+		return "required"
+	case errors.Is(err, openapi3filter.ErrInvalidEmptyValue):
+		return "empty"
+	case errors.As(err, &parseErr):
+		return "invalid_format"
+	default:
+		return "invalid"
+	}
+}
+
+func requestErrorMessage(reqErr *openapi3filter.RequestError) string {
+	switch {
+	case reqErr.Err != nil:
+		return reqErr.Err.Error()
+	case reqErr.Reason != "":
+		return reqErr.Reason
+	default:
+		return "invalid request"
+	}
+}
+
+// jsonPointer encodes reference tokens as an RFC 6901 JSON pointer.
+func jsonPointer(tokens []string) string {
+	var builder strings.Builder
+	for _, token := range tokens {
+		builder.WriteByte('/')
+		builder.WriteString(pointerEscaper.Replace(token))
+	}
+	return builder.String()
+}
@@ -0,0 +1,219 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+
+	"github.com/drblury/apiweaver/responder"
+)
+
+// ResponseValidationMode selects what happens to responses that do not match
+// the operation's declared responses.
+type ResponseValidationMode int
+
+const (
+	// ResponseValidationLogOnly validates every response, logs violations,
+	// and still delivers the original response.
+	ResponseValidationLogOnly ResponseValidationMode = iota
+	// ResponseValidationFail validates every response and replaces invalid
+	// ones with a 500 error.
+	ResponseValidationFail
+	// ResponseValidationSampled validates the SampleRate fraction of
+	// responses and logs violations, keeping the cost low in production.
+	ResponseValidationSampled
+)
+
+// ResponseValidationConfig configures WithResponseValidation.
+type ResponseValidationConfig struct {
+	Mode ResponseValidationMode
+	// SampleRate is the fraction of responses validated in
+	// ResponseValidationSampled mode, from 0 to 1.
+	SampleRate float64
+}
+
+func (cfg ResponseValidationConfig) samples() bool {
+	if cfg.Mode != ResponseValidationSampled {
+		return true
+	}
+	return rand.Float64() < cfg.SampleRate
+}
+
+// responseValidationMiddleware buffers the response of every operation found
+// in the spec and validates status, headers, and body with
+// openapi3filter.ValidateResponse before passing it on. Responses that are
+// flushed while being written, such as event streams, hijacked connections,
+// 304 Not Modified responses, and HEAD requests are delivered unvalidated.
+func responseValidationMiddleware(routes func() routers.Router, cfg ResponseValidationConfig, logger *slog.Logger, res *responder.Responder) Middleware {
+	return func(next http.Handler) http.Handler {
+		if logger == nil {
+			logger = slog.Default()
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			router := routes()
+			if router == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// HEAD responses carry no body to check against the schema.
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil || r.Method == http.MethodHead || !cfg.samples() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			buffered := &bufferedResponse{w: w, header: make(http.Header)}
+			next.ServeHTTP(buffered, r)
+			if buffered.streaming {
+				return
+			}
+			// A 304 answers a conditional request and is rarely declared in
+			// the spec, so it is passed on without validation.
+			if buffered.statusCode() == http.StatusNotModified {
+				buffered.flushTo(w)
+				return
+			}
+
+			if err := validateResponse(r, route, pathParams, buffered); err != nil {
+				attrs := []any{
+					"operationId", route.Operation.OperationID,
+					"method", r.Method,
+					"path", r.URL.Path,
+					"status", buffered.statusCode(),
+					"error", err,
+				}
+				if cfg.Mode == ResponseValidationFail {
+					logger.Error("response does not match the API specification", attrs...)
+					respondInvalidResponse(w, r, res, err)
+					return
+				}
+				logger.Warn("response does not match the API specification", attrs...)
+			}
+			buffered.flushTo(w)
+		})
+	}
+}
+
+func validateResponse(r *http.Request, route *routers.Route, pathParams map[string]string, buffered *bufferedResponse) error {
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status: buffered.statusCode(),
+		Header: buffered.header,
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+			MultiError:            true,
+		},
+	}
+	input.SetBodyBytes(buffered.body.Bytes())
+	return openapi3filter.ValidateResponse(r.Context(), input)
+}
+
+func respondInvalidResponse(w http.ResponseWriter, r *http.Request, res *responder.Responder, err error) {
+	if res == nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	invalid := responder.NewStatusError(http.StatusInternalServerError, err).
+		WithDetail("The response does not match the API specification.")
+	res.HandleAPIError(w, r, http.StatusInternalServerError, invalid, "response validation failed")
+}
+
+// bufferedResponse holds the handler's response until it has been validated.
+// A flush switches it to streaming: the buffered part is written out and
+// later writes go straight to the client.
+type bufferedResponse struct {
+	w           http.ResponseWriter
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+	streaming   bool
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	if b.streaming {
+		return b.w.Header()
+	}
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.wroteHeader {
+		return
+	}
+	b.wroteHeader, b.status = true, status
+	if b.streaming {
+		b.w.WriteHeader(status)
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if !b.wroteHeader {
+		b.WriteHeader(http.StatusOK)
+	}
+	if b.streaming {
+		return b.w.Write(p)
+	}
+	return b.body.Write(p)
+}
+
+// FlushError lets http.ResponseController flush through the buffer.
+func (b *bufferedResponse) FlushError() error {
+	if !b.streaming {
+		b.flushTo(b.w)
+		b.streaming = true
+	}
+	return http.NewResponseController(b.w).Flush()
+}
+
+// Flush implements http.Flusher for handlers that assert it directly.
+func (b *bufferedResponse) Flush() {
+	_ = b.FlushError()
+}
+
+// Hijack implements http.Hijacker. A hijacked connection is handed to the
+// handler as is, so the buffer is dropped and nothing is validated.
+func (b *bufferedResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(b.w).Hijack()
+	if err == nil {
+		b.streaming = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController.
+func (b *bufferedResponse) Unwrap() http.ResponseWriter {
+	return b.w
+}
+
+func (b *bufferedResponse) statusCode() int {
+	if b.status == 0 {
+		return http.StatusOK
+	}
+	return b.status
+}
+
+func (b *bufferedResponse) flushTo(w http.ResponseWriter) {
+	dst := w.Header()
+	for key, values := range b.header {
+		dst[key] = values
+	}
+	if b.wroteHeader || b.body.Len() > 0 {
+		w.WriteHeader(b.statusCode())
+	}
+	if b.body.Len() > 0 {
+		_, _ = w.Write(b.body.Bytes())
+	}
+}
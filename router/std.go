@@ -1,16 +1,20 @@
 package router
 
 import (
-	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
 	oapiMW "github.com/oapi-codegen/nethttp-middleware"
+
+	"github.com/drblury/apiweaver/responder"
 )
 
 // New returns a new *http.ServeMux configured with the provided handler and options.
@@ -26,6 +30,12 @@ func New(apiHandle http.Handler, opts ...Option) *http.ServeMux {
 		}
 	}
 
+	if len(settings.auth) > 0 && !settings.validatesRequests() {
+		// The authenticators run inside the validator; without it protected
+		// operations would be served to anyone.
+		panic("router: WithAuthenticator requires WithSwagger and the default OpenAPI validation middleware")
+	}
+
 	finalHandler := applyMiddlewares(apiHandle, settings.middlewareChain())
 	mux := http.NewServeMux()
 	mux.Handle("/", finalHandler)
@@ -48,7 +58,7 @@ func applyMiddlewares(handler http.Handler, middlewares []Middleware) http.Handl
 	return handler
 }
 
-func oapiMiddleware(swagger *openapi3.T) Middleware {
+func oapiMiddleware(swagger *openapi3.T, res *responder.Responder, authenticators map[string]Authenticator, routes func() routers.Router) Middleware {
 	return func(next http.Handler) http.Handler {
 		// Clear out the servers array in the swagger spec, that skips validating
 		// that server names match. We don't know how this thing will be run.
@@ -57,16 +67,49 @@ func oapiMiddleware(swagger *openapi3.T) Middleware {
 		// Validate requests against OpenAPI spec
 		validatorOptions := &oapiMW.Options{
 			Options: openapi3filter.Options{
-				AuthenticationFunc: func(c context.Context, input *openapi3filter.AuthenticationInput) error {
-					return nil
-				},
+				AuthenticationFunc: authenticate(authenticators),
 			},
 		}
 
-		return oapiMW.OapiRequestValidatorWithOptions(swagger, validatorOptions)(next)
+		if res != nil {
+			// Collect every failure so the problem document can list them all.
+			validatorOptions.Options.MultiError = true
+			validatorOptions.ErrorHandlerWithOpts = problemErrorHandler(res, routes, swagger)
+		}
+
+		validator := oapiMW.OapiRequestValidatorWithOptions(swagger, validatorOptions)
+		if len(authenticators) > 0 {
+			return authenticationMiddleware(validator, next)
+		}
+		return validator(next)
 	}
 }
 
+// specRouter builds the operation router used outside the validator, for
+// example to resolve allowed methods or operation ids. Like oapiMiddleware it
+// ignores the servers of the spec, but it leaves the caller's document as is.
+func specRouter(swagger *openapi3.T) (routers.Router, error) {
+	doc := *swagger
+	doc.Servers = nil
+	return gorillamux.NewRouter(&doc)
+}
+
+// lazyRouter returns the operation router of swagger, built on first use. A
+// spec the router cannot handle is logged once and disables the lookups that
+// depend on it instead of failing the request.
+func lazyRouter(swagger *openapi3.T, logger *slog.Logger) func() routers.Router {
+	return sync.OnceValue(func() routers.Router {
+		router, err := specRouter(swagger)
+		if err != nil {
+			if logger != nil {
+				logger.Warn("Cannot build OpenAPI operation router", "Error", err)
+			}
+			return nil
+		}
+		return router
+	})
+}
+
 func loggingMiddleware(logger *slog.Logger, quietdownRoutes []string, hideHeaders []string) Middleware {
 	logger.With(
 		"QuietdownRoutes", quietdownRoutes,
@@ -138,9 +181,13 @@ func corsMiddleware(cfg CORSConfig) Middleware {
 	}
 }
 
-// timeoutMiddleware adds timeout handling to requests.
-func timeoutMiddleware(timeout time.Duration) Middleware {
+// timeoutMiddleware adds timeout handling to requests. With a responder the
+// timeout response is a problem document instead of plain text.
+func timeoutMiddleware(timeout time.Duration, res *responder.Responder) Middleware {
 	return func(next http.Handler) http.Handler {
+		if res != nil {
+			return problemTimeoutHandler(next, timeout, res)
+		}
 		return http.TimeoutHandler(next, timeout, "Timeout")
 	}
 }
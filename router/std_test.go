@@ -1,11 +1,23 @@
 package router
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/drblury/apiweaver/responder"
 )
 
 func TestNewAllowsMiddlewareOverride(t *testing.T) {
@@ -172,6 +184,48 @@ func TestTimeoutMiddlewareCanBeDisabled(t *testing.T) {
 	}
 }
 
+func TestTimeoutMiddlewareStreamsFlushedResponses(t *testing.T) {
+	release := make(chan struct{})
+	writeErr := make(chan error, 1)
+	mux := New(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: one\n\n"))
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				t.Errorf("flush: %v", err)
+			}
+			<-release
+			<-r.Context().Done()
+			time.Sleep(20 * time.Millisecond) // let the timeout handler observe the deadline
+			_, err := w.Write([]byte("data: two\n\n"))
+			writeErr <- err
+		}),
+		WithConfig(Config{Timeout: 200 * time.Millisecond}),
+		WithResponder(responder.NewResponder(responder.WithLogger(slog.New(slog.DiscardHandler)))),
+		WithoutLoggingMiddleware(),
+	)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected the flushed stream before the deadline, got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	close(release)
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "data: one\n\n" {
+		t.Fatalf("unexpected stream body: %q", body)
+	}
+	if err := <-writeErr; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Fatalf("expected writes after the deadline to fail, got %v", err)
+	}
+}
+
 func TestNewPanicsWhenHandlerNil(t *testing.T) {
 	defer func() {
 		if recover() == nil {
@@ -182,6 +236,33 @@ func TestNewPanicsWhenHandlerNil(t *testing.T) {
 	New(nil)
 }
 
+func TestNewPanicsWhenAuthenticatorsCannotRun(t *testing.T) {
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(authTestSpec))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	auth := WithAuthenticator("apiKey", APIKeyAuthenticator(func(context.Context, string) (*Principal, error) {
+		return &Principal{}, nil
+	}))
+
+	cases := map[string][]Option{
+		"without swagger":    {auth},
+		"without validation": {auth, WithSwagger(swagger), WithoutOpenAPIValidation()},
+		"custom chain":       {auth, WithSwagger(swagger), WithMiddlewareChain(func(next http.Handler) http.Handler { return next })},
+	}
+	for name, opts := range cases {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected panic when authenticators cannot run")
+				}
+			}()
+
+			New(http.NotFoundHandler(), opts...)
+		})
+	}
+}
+
 func recordingMiddleware(label string, sink *[]string) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -191,3 +272,787 @@ func recordingMiddleware(label string, sink *[]string) Middleware {
 		})
 	}
 }
+
+const problemTestSpec = `
+openapi: 3.0.3
+info:
+  title: items
+  version: "1"
+paths:
+  /items:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: ok
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string
+                tags:
+                  type: array
+                  items:
+                    type: string
+      responses:
+        "201":
+          description: created
+`
+
+func TestWithResponderRendersValidationProblems(t *testing.T) {
+	mux := newProblemTestRouter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not run for invalid requests")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name":5,"tags":["a",1]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	problem := decodeProblem(t, rr, http.StatusBadRequest)
+	got := make(map[string]string)
+	for _, field := range problem.Errors {
+		got[field.Pointer] = field.Code
+	}
+	want := map[string]string{"/name": "type", "/tags/1": "type"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected field errors: got %v want %v", problem.Errors, want)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/items?limit=many", nil)
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	problem = decodeProblem(t, rr, http.StatusBadRequest)
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "limit" {
+		t.Fatalf("expected a field error for limit, got %+v", problem.Errors)
+	}
+}
+
+func TestWithResponderRendersRouteMisses(t *testing.T) {
+	mux := newProblemTestRouter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	decodeProblem(t, rr, http.StatusNotFound)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/items", nil))
+	decodeProblem(t, rr, http.StatusMethodNotAllowed)
+	if got := rr.Header().Get("Allow"); got != "GET, POST" {
+		t.Fatalf("unexpected Allow header: got %q want %q", got, "GET, POST")
+	}
+}
+
+func TestWithResponderRendersTimeoutProblem(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux := New(
+		handler,
+		WithConfig(Config{Timeout: 5 * time.Millisecond}),
+		WithResponder(responder.NewResponder(responder.WithLogger(slog.New(slog.DiscardHandler)))),
+		WithoutLoggingMiddleware(),
+	)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	decodeProblem(t, rr, http.StatusServiceUnavailable)
+}
+
+func newProblemTestRouter(t *testing.T, handler http.Handler) *http.ServeMux {
+	t.Helper()
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(problemTestSpec))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+
+	return New(
+		handler,
+		WithSwagger(swagger),
+		WithResponder(responder.NewResponder(responder.WithLogger(slog.New(slog.DiscardHandler)))),
+		WithoutLoggingMiddleware(),
+	)
+}
+
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder, status int) responder.ProblemDetails {
+	t.Helper()
+
+	if rr.Code != status {
+		t.Fatalf("unexpected status code: got %d want %d (body %s)", rr.Code, status, rr.Body.String())
+	}
+	if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/problem+json") {
+		t.Fatalf("unexpected content type: got %q", got)
+	}
+
+	var problem responder.ProblemDetails
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if problem.Status != status {
+		t.Fatalf("unexpected problem status: got %d want %d", problem.Status, status)
+	}
+	return problem
+}
+
+const authTestSpec = `
+openapi: 3.0.3
+info:
+  title: secured
+  version: "1"
+components:
+  securitySchemes:
+    apiKey:
+      type: apiKey
+      in: query
+      name: api_key
+    basic:
+      type: http
+      scheme: basic
+    bearer:
+      type: http
+      scheme: bearer
+paths:
+  /reports:
+    get:
+      security:
+        - apiKey: []
+        - basic: []
+      responses:
+        "200":
+          description: ok
+  /admin:
+    get:
+      security:
+        - bearer: [admin]
+      responses:
+        "200":
+          description: ok
+`
+
+func TestWithAuthenticatorStoresPrincipal(t *testing.T) {
+	mux := newAuthTestRouter(t)
+
+	cases := []struct {
+		name    string
+		target  string
+		prepare func(*http.Request)
+		subject string
+	}{
+		{name: "api key", target: "/reports?api_key=k-123", prepare: func(*http.Request) {}, subject: "key-client"},
+		{name: "basic", target: "/reports", prepare: func(r *http.Request) { r.SetBasicAuth("ada", "s3cret") }, subject: "ada"},
+		{name: "bearer", target: "/admin", prepare: func(r *http.Request) { r.Header.Set("Authorization", "Bearer root") }, subject: "root"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			tc.prepare(req)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("unexpected status code: got %d want %d (body %s)", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			if got := rr.Body.String(); got != tc.subject {
+				t.Fatalf("unexpected principal: got %q want %q", got, tc.subject)
+			}
+		})
+	}
+}
+
+func TestWithAuthenticatorRejectsWithProblems(t *testing.T) {
+	mux := newAuthTestRouter(t)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/reports", nil))
+	decodeProblem(t, rr, http.StatusUnauthorized)
+	if got := rr.Header().Get("WWW-Authenticate"); got != `Basic realm="api"` {
+		t.Fatalf("unexpected WWW-Authenticate: got %q", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/reports", nil)
+	req.SetBasicAuth("ada", "wrong")
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	decodeProblem(t, rr, http.StatusUnauthorized)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.Header.Set("Authorization", "Bearer reader")
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	decodeProblem(t, rr, http.StatusForbidden)
+}
+
+func TestWithAuthenticatorDropsPrincipalOfFailedRequirement(t *testing.T) {
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(strings.Replace(authTestSpec, `      security:
+        - apiKey: []
+        - basic: []
+`, `      security:
+        - apiKey: []
+          basic: []
+        - bearer: []
+        - {}
+`, 1)))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+
+	mux := New(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			subject := "anonymous"
+			if principal, ok := PrincipalFromContext(r.Context()); ok {
+				subject = principal.Scheme + ":" + principal.Subject
+			}
+			_, _ = w.Write([]byte(subject))
+		}),
+		WithSwagger(swagger),
+		WithAuthenticator("apiKey", APIKeyAuthenticator(func(context.Context, string) (*Principal, error) {
+			return &Principal{Subject: "key-client"}, nil
+		})),
+		WithAuthenticator("basic", BasicAuthenticator(HashedCredentials{"ada": string(hash)})),
+		WithAuthenticator("bearer", BearerAuthenticator(func(_ context.Context, token string) (*Principal, error) {
+			return &Principal{Subject: token}, nil
+		})),
+		WithoutLoggingMiddleware(),
+	)
+
+	cases := []struct {
+		name    string
+		prepare func(*http.Request)
+		want    string
+	}{
+		{name: "all schemes pass", prepare: func(r *http.Request) { r.SetBasicAuth("ada", "s3cret") }, want: "basic:ada"},
+		{name: "next alternative", prepare: func(r *http.Request) { r.Header.Set("Authorization", "Bearer tok") }, want: "bearer:tok"},
+		{name: "anonymous alternative", prepare: func(r *http.Request) { r.SetBasicAuth("ada", "wrong") }, want: "anonymous"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/reports?api_key=k-123", nil)
+			tc.prepare(req)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK || rr.Body.String() != tc.want {
+				t.Fatalf("unexpected response: got %d %q want %q", rr.Code, rr.Body.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestWithAuthenticatorSkipsRequirementWithUndeclaredScheme(t *testing.T) {
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(strings.Replace(authTestSpec, `      security:
+        - apiKey: []
+        - basic: []
+`, `      security:
+        - apiKey: []
+          undeclared: []
+        - basic: []
+`, 1)))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+
+	mux := New(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				t.Error("expected a principal")
+				return
+			}
+			_, _ = w.Write([]byte(principal.Scheme + ":" + principal.Subject))
+		}),
+		WithSwagger(swagger),
+		WithAuthenticator("apiKey", APIKeyAuthenticator(func(context.Context, string) (*Principal, error) {
+			return &Principal{Subject: "key-client"}, nil
+		})),
+		WithAuthenticator("basic", BasicAuthenticator(HashedCredentials{"ada": string(hash)})),
+		WithoutLoggingMiddleware(),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/reports?api_key=k-123", nil)
+	req.SetBasicAuth("ada", "s3cret")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK || rr.Body.String() != "basic:ada" {
+		t.Fatalf("unexpected response: got %d %q want %q", rr.Code, rr.Body.String(), "basic:ada")
+	}
+}
+
+func TestWithAuthenticatorCopiesSharedPrincipal(t *testing.T) {
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(authTestSpec))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+
+	shared := &Principal{Subject: "shared", Scopes: []string{"admin"}}
+	verify := func(context.Context, string) (*Principal, error) { return shared, nil }
+	mux := New(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ := PrincipalFromContext(r.Context())
+			_, _ = w.Write([]byte(principal.Scheme))
+		}),
+		WithSwagger(swagger),
+		WithAuthenticator("apiKey", APIKeyAuthenticator(verify)),
+		WithAuthenticator("bearer", BearerAuthenticator(verify)),
+		WithoutLoggingMiddleware(),
+	)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/reports?api_key=k", nil))
+			if rr.Body.String() != "apiKey" {
+				t.Errorf("unexpected scheme: got %q want %q", rr.Body.String(), "apiKey")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.Header.Set("Authorization", "Bearer t")
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			if rr.Body.String() != "bearer" {
+				t.Errorf("unexpected scheme: got %q want %q", rr.Body.String(), "bearer")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if shared.Scheme != "" {
+		t.Fatalf("shared principal modified: scheme %q", shared.Scheme)
+	}
+}
+
+func newAuthTestRouter(t *testing.T) *http.ServeMux {
+	t.Helper()
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(authTestSpec))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			t.Error("expected an authenticated principal")
+			return
+		}
+		_, _ = w.Write([]byte(principal.Subject))
+	})
+
+	return New(
+		handler,
+		WithSwagger(swagger),
+		WithResponder(responder.NewResponder(responder.WithLogger(slog.New(slog.DiscardHandler)))),
+		WithAuthenticator("apiKey", APIKeyAuthenticator(func(_ context.Context, key string) (*Principal, error) {
+			if key != "k-123" {
+				return nil, ErrInvalidCredentials
+			}
+			return &Principal{Subject: "key-client"}, nil
+		})),
+		WithAuthenticator("basic", BasicAuthenticator(HashedCredentials{"ada": string(hash)})),
+		WithAuthenticator("bearer", BearerAuthenticator(func(_ context.Context, token string) (*Principal, error) {
+			principal := &Principal{Subject: token}
+			if token == "root" {
+				principal.Scopes = []string{"admin"}
+			}
+			return principal, nil
+		})),
+		WithoutLoggingMiddleware(),
+	)
+}
+
+const responseTestSpec = `
+openapi: 3.0.3
+info:
+  title: items
+  version: "1"
+paths:
+  /items/{id}:
+    get:
+      operationId: getItem
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id]
+                properties:
+                  id:
+                    type: integer
+`
+
+func TestWithResponseValidationModes(t *testing.T) {
+	cases := []struct {
+		name   string
+		cfg    ResponseValidationConfig
+		status int
+		body   string
+		logged bool
+	}{
+		{name: "valid", cfg: ResponseValidationConfig{Mode: ResponseValidationFail}, body: `{"id":1}`, status: http.StatusOK},
+		{name: "log only", cfg: ResponseValidationConfig{Mode: ResponseValidationLogOnly}, body: `{"id":"one"}`, status: http.StatusOK, logged: true},
+		{name: "fail", cfg: ResponseValidationConfig{Mode: ResponseValidationFail}, body: `{"id":"one"}`, status: http.StatusInternalServerError, logged: true},
+		{name: "sampled out", cfg: ResponseValidationConfig{Mode: ResponseValidationSampled, SampleRate: 0}, body: `{"id":"one"}`, status: http.StatusOK},
+		{name: "sampled in", cfg: ResponseValidationConfig{Mode: ResponseValidationSampled, SampleRate: 1}, body: `{"id":"one"}`, status: http.StatusOK, logged: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs strings.Builder
+			mux := newResponseTestRouter(t, tc.cfg, &logs, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.body))
+			}))
+
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+
+			if rr.Code != tc.status {
+				t.Fatalf("unexpected status code: got %d want %d (body %s)", rr.Code, tc.status, rr.Body.String())
+			}
+			if tc.status == http.StatusOK && rr.Body.String() != tc.body {
+				t.Fatalf("unexpected body: got %q want %q", rr.Body.String(), tc.body)
+			}
+			if logged := strings.Contains(logs.String(), "operationId=getItem"); logged != tc.logged {
+				t.Fatalf("unexpected violation log %v: %s", logged, logs.String())
+			}
+		})
+	}
+}
+
+func TestWithResponseValidationRejectsUndeclaredStatus(t *testing.T) {
+	var logs strings.Builder
+	mux := newResponseTestRouter(t, ResponseValidationConfig{Mode: ResponseValidationFail}, &logs,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}))
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	decodeProblem(t, rr, http.StatusInternalServerError)
+}
+
+func TestWithResponseValidationPassesStreamsThrough(t *testing.T) {
+	var logs strings.Builder
+	mux := newResponseTestRouter(t, ResponseValidationConfig{Mode: ResponseValidationFail}, &logs,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: one\n\n"))
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				t.Errorf("flush: %v", err)
+			}
+			_, _ = w.Write([]byte("data: two\n\n"))
+		}))
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+
+	if rr.Code != http.StatusOK || rr.Body.String() != "data: one\n\ndata: two\n\n" {
+		t.Fatalf("unexpected streamed response: %d %q", rr.Code, rr.Body.String())
+	}
+	if !rr.Flushed {
+		t.Fatal("expected the stream to be flushed")
+	}
+}
+
+func TestWithResponseValidationSkipsNotModified(t *testing.T) {
+	var logs strings.Builder
+	mux := newResponseTestRouter(t, ResponseValidationConfig{Mode: ResponseValidationFail}, &logs,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			w.WriteHeader(http.StatusNotModified)
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set("If-None-Match", `"v1"`)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotModified || rr.Header().Get("ETag") != `"v1"` {
+		t.Fatalf("unexpected conditional response: %d %v", rr.Code, rr.Header())
+	}
+	if strings.Contains(logs.String(), "does not match") {
+		t.Fatalf("expected no violation for 304, got %s", logs.String())
+	}
+}
+
+func TestWithResponseValidationAllowsHijack(t *testing.T) {
+	var logs strings.Builder
+	mux := newResponseTestRouter(t, ResponseValidationConfig{Mode: ResponseValidationFail}, &logs,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, buf, err := http.NewResponseController(w).Hijack()
+			if err != nil {
+				t.Errorf("hijack: %v", err)
+				return
+			}
+			defer conn.Close()
+			_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+			_ = buf.Flush()
+		}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/items/1")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "hijacked" {
+		t.Fatalf("unexpected hijacked response: %q", body)
+	}
+}
+
+func newResponseTestRouter(t *testing.T, cfg ResponseValidationConfig, logs *strings.Builder, handler http.Handler) *http.ServeMux {
+	t.Helper()
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(responseTestSpec))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+
+	return New(
+		handler,
+		WithSwagger(swagger),
+		WithLogger(slog.New(slog.NewTextHandler(logs, nil))),
+		WithResponder(responder.NewResponder(responder.WithLogger(slog.New(slog.DiscardHandler)))),
+		WithResponseValidation(cfg),
+		WithoutTimeoutMiddleware(),
+	)
+}
+
+func TestLoggingMiddlewareWritesAccessLog(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		cfg    AccessLogConfig
+		want   []string
+	}{
+		{name: "success", status: http.StatusCreated, want: []string{"level=INFO", "Status=201", "BytesWritten=5", "OperationID=getItem", "TraceID=trace-1"}},
+		{name: "client error", status: http.StatusNotFound, want: []string{"level=WARN", "Status=404"}},
+		{name: "server error", status: http.StatusBadGateway, want: []string{"level=ERROR", "Status=502"}},
+		{name: "sampled out", status: http.StatusOK, cfg: AccessLogConfig{SuccessSampleRate: 1e-12}},
+		{name: "sampled errors", status: http.StatusInternalServerError, cfg: AccessLogConfig{SuccessSampleRate: 1e-12}, want: []string{"level=ERROR"}},
+		{name: "slow", status: http.StatusOK, cfg: AccessLogConfig{SuccessSampleRate: 1e-12, SlowThreshold: time.Nanosecond}, want: []string{"level=WARN", "Slow=true"}},
+		{name: "custom level", status: http.StatusNotFound, cfg: AccessLogConfig{Level: func(int) slog.Level { return slog.LevelInfo }}, want: []string{"level=INFO", "Status=404"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			swagger, err := openapi3.NewLoader().LoadFromData([]byte(responseTestSpec))
+			if err != nil {
+				t.Fatalf("load spec: %v", err)
+			}
+			var logs strings.Builder
+			mux := New(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(tc.status)
+					_, _ = w.Write([]byte("hello"))
+				}),
+				WithSwagger(swagger),
+				WithoutOpenAPIValidation(),
+				WithResponder(responder.NewResponder(responder.WithLogger(slog.New(slog.DiscardHandler)))),
+				WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+				WithConfigMutator(func(cfg *Config) { cfg.AccessLog = tc.cfg }),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+			req.Header.Set("X-Request-ID", "trace-1")
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+
+			line := logs.String()
+			if len(tc.want) == 0 {
+				if line != "" {
+					t.Fatalf("expected no access log, got %s", line)
+				}
+				return
+			}
+			if !strings.Contains(line, `msg="Request completed"`) {
+				t.Fatalf("expected a completion log, got %q", line)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(line, want) {
+					t.Fatalf("expected %q in access log %q", want, line)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordingWriterPreservesFlushAndHijack(t *testing.T) {
+	rr := httptest.NewRecorder()
+	recorder := &recordingWriter{ResponseWriter: rr}
+	if err := http.NewResponseController(recorder).Flush(); err != nil || !rr.Flushed {
+		t.Fatalf("expected flush to reach the wrapped writer: %v", err)
+	}
+
+	mux := New(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, buf, err := http.NewResponseController(w).Hijack()
+			if err != nil {
+				t.Errorf("hijack: %v", err)
+				return
+			}
+			defer conn.Close()
+			_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+			_ = buf.Flush()
+		}),
+		WithoutTimeoutMiddleware(),
+		WithLogger(slog.New(slog.DiscardHandler)),
+	)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "hijacked" {
+		t.Fatalf("unexpected hijacked response: %q", body)
+	}
+}
+
+func TestAccessLogRecordsRejectedRequests(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		target string
+		want   []string
+	}{
+		{name: "validation failure", method: http.MethodGet, target: "/items?limit=abc", want: []string{"level=WARN", "Status=400", "TraceID=trace-1"}},
+		{name: "method not allowed", method: http.MethodDelete, target: "/items", want: []string{"level=WARN", "Status=405"}},
+		{name: "timeout", method: http.MethodGet, target: "/items?limit=1", want: []string{"level=ERROR", "Status=503"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			swagger, err := openapi3.NewLoader().LoadFromData([]byte(problemTestSpec))
+			if err != nil {
+				t.Fatalf("load spec: %v", err)
+			}
+			var logs strings.Builder
+			mux := New(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					<-r.Context().Done()
+					w.WriteHeader(http.StatusOK)
+				}),
+				WithSwagger(swagger),
+				WithResponder(responder.NewResponder(responder.WithLogger(slog.New(slog.DiscardHandler)))),
+				WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+				WithConfigMutator(func(cfg *Config) { cfg.Timeout = 10 * time.Millisecond }),
+			)
+
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			req.Header.Set("X-Request-ID", "trace-1")
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+
+			line := logs.String()
+			for _, want := range tc.want {
+				if !strings.Contains(line, want) {
+					t.Fatalf("expected %q in access log %q", want, line)
+				}
+			}
+		})
+	}
+}
+
+func TestAccessLogToleratesUnroutableSpec(t *testing.T) {
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.3
+info:
+  title: broken
+  version: "1"
+paths:
+  /items/{id:
+    get:
+      responses:
+        "200":
+          description: ok
+`))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+
+	var logs strings.Builder
+	mux := New(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}),
+		WithSwagger(swagger),
+		WithoutOpenAPIValidation(),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status code: got %d want %d", rr.Code, http.StatusNotFound)
+	}
+	for _, want := range []string{"Cannot build OpenAPI operation router", "Status=404"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Fatalf("expected %q in logs %q", want, logs.String())
+		}
+	}
+}
+
+func TestOperationRouterLeavesSwaggerServers(t *testing.T) {
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(responseTestSpec))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	swagger.Servers = openapi3.Servers{{URL: "https://api.example.com"}}
+
+	New(
+		http.NotFoundHandler(),
+		WithSwagger(swagger),
+		WithoutOpenAPIValidation(),
+		WithResponseValidation(ResponseValidationConfig{}),
+		WithLogger(slog.New(slog.DiscardHandler)),
+	)
+	if len(swagger.Servers) != 1 {
+		t.Fatalf("expected the servers of the caller's document to be kept, got %v", swagger.Servers)
+	}
+}
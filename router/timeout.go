@@ -0,0 +1,171 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/drblury/apiweaver/responder"
+)
+
+// problemTimeoutHandler behaves like http.TimeoutHandler but answers requests
+// that exceed the deadline with a 503 problem document. The handler's output
+// is buffered until it returns, so nothing reaches the client once the
+// deadline has passed. Handlers that flush, such as event streams, switch the
+// response to pass-through; the deadline then only cancels the request
+// context and stops further writes, since the status has already been sent.
+func problemTimeoutHandler(next http.Handler, timeout time.Duration, res *responder.Responder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		tw := &timeoutWriter{w: w, header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			next.ServeHTTP(tw, r)
+			close(done)
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+			tw.flushTo(w)
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			if tw.passThrough {
+				tw.err = http.ErrHandlerTimeout
+				return
+			}
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				tw.err = ctx.Err()
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			tw.err = http.ErrHandlerTimeout
+			res.HandleAPIError(w, r, http.StatusServiceUnavailable,
+				fmt.Errorf("request did not complete within %s", timeout), "request timed out")
+		}
+	})
+}
+
+type timeoutWriter struct {
+	w           http.ResponseWriter
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	passThrough bool
+	err         error
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.header }
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.err != nil {
+		return 0, tw.err
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	if tw.passThrough {
+		return tw.w.Write(p)
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeaderLocked(code)
+}
+
+// writeHeaderLocked mirrors the checks of http.TimeoutHandler: invalid codes
+// panic and calls after the first one are ignored.
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	if code < 100 || code > 999 {
+		panic(fmt.Sprintf("invalid WriteHeader code %v", code))
+	}
+	if tw.err != nil || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader, tw.code = true, code
+}
+
+// FlushError writes the buffered response to the client and switches to
+// pass-through, so streamed records are not held back until the handler
+// returns.
+func (tw *timeoutWriter) FlushError() error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.err != nil {
+		return tw.err
+	}
+	if !tw.passThrough {
+		tw.writeBufferedLocked(tw.w)
+		tw.passThrough = true
+	}
+	return http.NewResponseController(tw.w).Flush()
+}
+
+// Flush implements http.Flusher for handlers that assert it directly.
+func (tw *timeoutWriter) Flush() {
+	_ = tw.FlushError()
+}
+
+// Hijack implements http.Hijacker. The handler owns the connection
+// afterwards, so the deadline no longer produces a response.
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.err != nil {
+		return nil, nil, tw.err
+	}
+	conn, rw, err := http.NewResponseController(tw.w).Hijack()
+	if err == nil {
+		tw.passThrough = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController.
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.w
+}
+
+func (tw *timeoutWriter) flushTo(w http.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.passThrough {
+		tw.writeBufferedLocked(w)
+	}
+}
+
+func (tw *timeoutWriter) writeBufferedLocked(w http.ResponseWriter) {
+	dst := w.Header()
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+	if !tw.wroteHeader {
+		tw.wroteHeader, tw.code = true, http.StatusOK
+	}
+	w.WriteHeader(tw.code)
+	_, _ = w.Write(tw.buf.Bytes())
+}